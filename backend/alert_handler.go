@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func handleListAlerts(c *fiber.Ctx) error {
+	active, recent := appUpdater.Alerts()
+	return c.JSON(fiber.Map{
+		"active": active,
+		"recent": recent,
+	})
+}
+
+func handleTestNotifier(c *fiber.Ctx) error {
+	err := appUpdater.TestNotifier(c.Params("name"))
+	if errors.Is(err, ErrNotifierNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Notifier not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "ok",
+	})
+}
@@ -0,0 +1,661 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	AlertAppDown       = "app-down"
+	AlertDeployFailed  = "deploy-failed"
+	AlertRestartStorm  = "restart-storm"
+	AlertDiskUsage     = "disk-usage"
+	AlertCertExpiry    = "cert-expiry"
+	AlertFiring        = "firing"
+	AlertResolved      = "resolved"
+	AlertCritical      = "critical"
+	AlertWarning       = "warning"
+	alertEvalInterval  = 15 * time.Second
+	alertHistorySize   = 200
+	certCheckInterval  = time.Hour
+	defaultDownFor     = 30 * time.Second
+	defaultStormWindow = 5 * time.Minute
+	defaultStormCount  = 5
+	defaultDiskPercent = 90
+	defaultCertDays    = 14
+)
+
+var ErrNotifierNotFound = errors.New("notifier not found")
+
+type AlertRule struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Apps      []string `json:"apps,omitempty"`
+	Severity  string   `json:"severity,omitempty"`
+	For       string   `json:"for,omitempty"`
+	Restarts  int      `json:"restarts,omitempty"`
+	Window    string   `json:"window,omitempty"`
+	Threshold float64  `json:"threshold,omitempty"`
+	Path      string   `json:"path,omitempty"`
+	Host      string   `json:"host,omitempty"`
+	Days      int      `json:"days,omitempty"`
+	Repeat    string   `json:"repeat,omitempty"`
+	Notifiers []string `json:"notifiers,omitempty"`
+}
+
+type Alert struct {
+	Rule     string            `json:"rule"`
+	Type     string            `json:"type"`
+	Severity string            `json:"severity"`
+	Status   string            `json:"status"`
+	Subject  string            `json:"subject"`
+	AppKey   string            `json:"appKey,omitempty"`
+	Summary  string            `json:"summary"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	StartsAt time.Time         `json:"startsAt"`
+	EndsAt   *time.Time        `json:"endsAt,omitempty"`
+}
+
+func (r AlertRule) matchesApp(appKey string) bool {
+	return len(r.Apps) == 0 || slices.Contains(r.Apps, appKey)
+}
+
+func (r AlertRule) severity() string {
+	if r.Severity != "" {
+		return r.Severity
+	}
+	if r.Type == AlertAppDown || r.Type == AlertDeployFailed {
+		return AlertCritical
+	}
+	return AlertWarning
+}
+
+func (r AlertRule) validate() error {
+	switch r.Type {
+	case AlertAppDown, AlertDeployFailed, AlertRestartStorm:
+	case AlertDiskUsage:
+		if r.Threshold < 0 || r.Threshold > 100 {
+			return fmt.Errorf("disk usage threshold must be between 0 and 100")
+		}
+	case AlertCertExpiry:
+		if r.Host == "" {
+			return fmt.Errorf("certificate expiry rule requires a host")
+		}
+	default:
+		return fmt.Errorf("unknown alert rule type %q", r.Type)
+	}
+
+	for _, spec := range []string{r.For, r.Window, r.Repeat} {
+		if spec == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(spec); err != nil || d < 0 {
+			return fmt.Errorf("invalid duration %q", spec)
+		}
+	}
+	return nil
+}
+
+func (r AlertRule) downFor() time.Duration {
+	if r.For == "" {
+		return defaultDownFor
+	}
+	d, _ := time.ParseDuration(r.For)
+	return d
+}
+
+func (r AlertRule) diskPath() string {
+	if r.Path != "" {
+		return r.Path
+	}
+	return zenRoot
+}
+
+func (r AlertRule) certAddress() string {
+	if _, _, err := net.SplitHostPort(r.Host); err == nil {
+		return r.Host
+	}
+	return net.JoinHostPort(r.Host, "443")
+}
+
+type activeAlert struct {
+	alert      Alert
+	notifiedAt time.Time
+}
+
+type restartDelta struct {
+	time  time.Time
+	count int
+}
+
+type certResult struct {
+	expiry    time.Time
+	err       error
+	checkedAt time.Time
+}
+
+type namedSender struct {
+	name   string
+	sender NotifierSender
+}
+
+type alertNotification struct {
+	alert   Alert
+	senders []namedSender
+}
+
+type alertManager struct {
+	mu            sync.Mutex
+	config        []AlertRule
+	notifierSpecs []Notifier
+	rules         []AlertRule
+	notifiers     map[string]NotifierSender
+	notifierOrder []string
+	active        map[string]*activeAlert
+	downSince     map[string]time.Time
+	restartTotals map[string]int
+	restarts      map[string][]restartDelta
+	certs         map[string]certResult
+	recent        []Alert
+
+	diskUsage  func(path string) (float64, error)
+	certExpiry func(address string) (time.Time, error)
+}
+
+func newAlertManager() *alertManager {
+	return &alertManager{
+		notifiers:     make(map[string]NotifierSender),
+		active:        make(map[string]*activeAlert),
+		downSince:     make(map[string]time.Time),
+		restartTotals: make(map[string]int),
+		restarts:      make(map[string][]restartDelta),
+		certs:         make(map[string]certResult),
+		diskUsage:     diskUsagePercent,
+		certExpiry:    fetchCertExpiry,
+	}
+}
+
+func (am *alertManager) Configure(rules []AlertRule, notifiers []Notifier) {
+	am.mu.Lock()
+	if reflect.DeepEqual(am.config, rules) && reflect.DeepEqual(am.notifierSpecs, notifiers) {
+		am.mu.Unlock()
+		return
+	}
+
+	invalid := make(map[string]error)
+	var valid []AlertRule
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			invalid["rule "+rule.Name] = err
+			continue
+		}
+		valid = append(valid, rule)
+		names[rule.Name] = true
+	}
+
+	senders := make(map[string]NotifierSender)
+	var order []string
+	for _, notifier := range notifiers {
+		sender, err := newNotifierSender(notifier)
+		if err != nil {
+			invalid["notifier "+notifier.Name] = err
+			continue
+		}
+		senders[notifier.Name] = sender
+		order = append(order, notifier.Name)
+	}
+
+	for key, active := range am.active {
+		if !names[active.alert.Rule] {
+			delete(am.active, key)
+		}
+	}
+	am.config = rules
+	am.notifierSpecs = notifiers
+	am.rules = valid
+	am.notifiers = senders
+	am.notifierOrder = order
+	am.mu.Unlock()
+
+	for name, err := range invalid {
+		subsystemLogger("alerts").Error("Invalid alert configuration", "name", name, "error", err)
+	}
+}
+
+func (am *alertManager) sendersFor(rule AlertRule) []namedSender {
+	names := rule.Notifiers
+	if len(names) == 0 {
+		names = am.notifierOrder
+	}
+
+	var senders []namedSender
+	for _, name := range names {
+		if sender, ok := am.notifiers[name]; ok {
+			senders = append(senders, namedSender{name: name, sender: sender})
+		}
+	}
+	return senders
+}
+
+func (am *alertManager) remember(alert Alert) {
+	am.recent = append(am.recent, alert)
+	if overflow := len(am.recent) - alertHistorySize; overflow > 0 {
+		am.recent = am.recent[overflow:]
+	}
+}
+
+func (am *alertManager) Evaluate(now time.Time, processes map[string]*ProcessInfo) {
+	am.mu.Lock()
+	rules := slices.Clone(am.rules)
+	am.mu.Unlock()
+
+	disk := make(map[string]float64)
+	for _, rule := range rules {
+		switch rule.Type {
+		case AlertDiskUsage:
+			path := rule.diskPath()
+			if _, ok := disk[path]; ok {
+				continue
+			}
+			percent, err := am.diskUsage(path)
+			if err != nil {
+				subsystemLogger("alerts").Warn("Failed to read disk usage", "path", path, "error", err)
+				continue
+			}
+			disk[path] = percent
+		case AlertCertExpiry:
+			am.refreshCert(rule.certAddress(), now)
+		}
+	}
+
+	am.mu.Lock()
+	am.trackProcesses(now, processes)
+
+	firing := make(map[string]bool)
+	var notifications []alertNotification
+	for _, rule := range rules {
+		alerts, known := am.conditions(rule, now, processes, disk)
+		if !known {
+			for key, active := range am.active {
+				if active.alert.Rule == rule.Name {
+					firing[key] = true
+				}
+			}
+			continue
+		}
+
+		for _, alert := range alerts {
+			key := rule.Name + "\xff" + alert.Subject
+			firing[key] = true
+			if notification, ok := am.fire(key, rule, alert, now); ok {
+				notifications = append(notifications, notification)
+			}
+		}
+	}
+
+	for key, active := range am.active {
+		if firing[key] {
+			continue
+		}
+
+		resolved := active.alert
+		resolved.Status = AlertResolved
+		resolved.EndsAt = &now
+		delete(am.active, key)
+		am.remember(resolved)
+
+		for _, rule := range rules {
+			if rule.Name == resolved.Rule {
+				notifications = append(notifications, alertNotification{alert: resolved, senders: am.sendersFor(rule)})
+			}
+		}
+	}
+	am.mu.Unlock()
+
+	am.dispatch(notifications)
+}
+
+func (am *alertManager) trackProcesses(now time.Time, processes map[string]*ProcessInfo) {
+	window := defaultStormWindow
+	for _, rule := range am.rules {
+		if rule.Type == AlertRestartStorm {
+			window = max(window, parseDurationOr(rule.Window, defaultStormWindow))
+		}
+	}
+
+	for appKey, info := range processes {
+		running, restarts := 0, 0
+		for _, state := range info.Processes {
+			if state.Running {
+				running++
+			}
+			restarts += state.Restarts
+		}
+
+		if len(info.Processes) > 0 && running == 0 {
+			if _, ok := am.downSince[appKey]; !ok {
+				am.downSince[appKey] = now
+			}
+		} else {
+			delete(am.downSince, appKey)
+		}
+
+		if previous, ok := am.restartTotals[appKey]; ok && restarts > previous {
+			am.restarts[appKey] = append(am.restarts[appKey], restartDelta{time: now, count: restarts - previous})
+		}
+		am.restartTotals[appKey] = restarts
+
+		deltas := am.restarts[appKey]
+		for len(deltas) > 0 && now.Sub(deltas[0].time) > window {
+			deltas = deltas[1:]
+		}
+		am.restarts[appKey] = deltas
+	}
+
+	for appKey := range am.restartTotals {
+		if _, ok := processes[appKey]; !ok {
+			delete(am.downSince, appKey)
+			delete(am.restartTotals, appKey)
+			delete(am.restarts, appKey)
+		}
+	}
+}
+
+func (am *alertManager) conditions(rule AlertRule, now time.Time, processes map[string]*ProcessInfo, disk map[string]float64) ([]Alert, bool) {
+	var alerts []Alert
+
+	switch rule.Type {
+	case AlertAppDown:
+		downFor := rule.downFor()
+		for appKey := range processes {
+			since, down := am.downSince[appKey]
+			if !down || !rule.matchesApp(appKey) || now.Sub(since) < downFor {
+				continue
+			}
+			alerts = append(alerts, Alert{
+				Subject:  appKey,
+				AppKey:   appKey,
+				Summary:  fmt.Sprintf("%s has had no running processes for %s", appKey, now.Sub(since).Round(time.Second)),
+				StartsAt: since,
+			})
+		}
+
+	case AlertRestartStorm:
+		window := parseDurationOr(rule.Window, defaultStormWindow)
+		threshold := positiveOr(rule.Restarts, defaultStormCount)
+		for appKey := range processes {
+			if !rule.matchesApp(appKey) {
+				continue
+			}
+			count := 0
+			for _, delta := range am.restarts[appKey] {
+				if now.Sub(delta.time) <= window {
+					count += delta.count
+				}
+			}
+			if count >= threshold {
+				alerts = append(alerts, Alert{
+					Subject:  appKey,
+					AppKey:   appKey,
+					Summary:  fmt.Sprintf("%s restarted %d times in the last %s", appKey, count, window),
+					Labels:   map[string]string{"restarts": fmt.Sprint(count)},
+					StartsAt: now,
+				})
+			}
+		}
+
+	case AlertDiskUsage:
+		path := rule.diskPath()
+		percent, ok := disk[path]
+		if !ok {
+			return nil, false
+		}
+		threshold := rule.Threshold
+		if threshold == 0 {
+			threshold = defaultDiskPercent
+		}
+		if percent >= threshold {
+			alerts = append(alerts, Alert{
+				Subject:  path,
+				Summary:  fmt.Sprintf("Disk usage on %s is %.1f%% (threshold %.0f%%)", path, percent, threshold),
+				Labels:   map[string]string{"usage": fmt.Sprintf("%.1f", percent)},
+				StartsAt: now,
+			})
+		}
+
+	case AlertCertExpiry:
+		address := rule.certAddress()
+		result, ok := am.certs[address]
+		if !ok || result.err != nil {
+			return nil, false
+		}
+		remaining := result.expiry.Sub(now)
+		if remaining < time.Duration(positiveOr(rule.Days, defaultCertDays))*24*time.Hour {
+			summary := fmt.Sprintf("Certificate for %s expires in %d days (%s)", address, int(remaining.Hours()/24), result.expiry.Format(time.RFC3339))
+			if remaining <= 0 {
+				summary = fmt.Sprintf("Certificate for %s expired on %s", address, result.expiry.Format(time.RFC3339))
+			}
+			alerts = append(alerts, Alert{
+				Subject:  address,
+				Summary:  summary,
+				Labels:   map[string]string{"expiry": result.expiry.Format(time.RFC3339)},
+				StartsAt: now,
+			})
+		}
+
+	default:
+		return nil, true
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Subject < alerts[j].Subject
+	})
+	return alerts, true
+}
+
+func (am *alertManager) fire(key string, rule AlertRule, alert Alert, now time.Time) (alertNotification, bool) {
+	alert.Rule = rule.Name
+	alert.Type = rule.Type
+	alert.Severity = rule.severity()
+	alert.Status = AlertFiring
+
+	if existing, ok := am.active[key]; ok {
+		existing.alert.Summary = alert.Summary
+		existing.alert.Labels = alert.Labels
+		repeat := parseDurationOr(rule.Repeat, 0)
+		if repeat <= 0 || now.Sub(existing.notifiedAt) < repeat {
+			return alertNotification{}, false
+		}
+		existing.notifiedAt = now
+		return alertNotification{alert: existing.alert, senders: am.sendersFor(rule)}, true
+	}
+
+	am.active[key] = &activeAlert{alert: alert, notifiedAt: now}
+	am.remember(alert)
+	alertsFiredTotal.Inc(rule.Name, rule.Type)
+	return alertNotification{alert: alert, senders: am.sendersFor(rule)}, true
+}
+
+func (am *alertManager) refreshCert(address string, now time.Time) {
+	am.mu.Lock()
+	result, ok := am.certs[address]
+	am.mu.Unlock()
+	if ok && now.Sub(result.checkedAt) < certCheckInterval {
+		return
+	}
+
+	expiry, err := am.certExpiry(address)
+	if err != nil {
+		subsystemLogger("alerts").Warn("Failed to check certificate", "address", address, "error", err)
+	}
+
+	am.mu.Lock()
+	am.certs[address] = certResult{expiry: expiry, err: err, checkedAt: now}
+	am.mu.Unlock()
+}
+
+func (am *alertManager) DeployFailed(deployment Deployment) {
+	am.mu.Lock()
+	var notifications []alertNotification
+	for _, rule := range am.rules {
+		if rule.Type != AlertDeployFailed || !rule.matchesApp(deployment.AppKey) {
+			continue
+		}
+
+		alert := Alert{
+			Rule:     rule.Name,
+			Type:     rule.Type,
+			Severity: rule.severity(),
+			Status:   AlertFiring,
+			Subject:  deployment.AppKey,
+			AppKey:   deployment.AppKey,
+			Summary:  fmt.Sprintf("Deployment of %s %s %s: %s", deployment.AppKey, deployment.Version, deployment.Status, deployment.Error),
+			Labels: map[string]string{
+				"deployment":      deployment.ID,
+				"version":         deployment.Version,
+				"previousVersion": deployment.PreviousVersion,
+				"status":          deployment.Status,
+			},
+			StartsAt: deployment.FinishedAt,
+		}
+		am.remember(alert)
+		alertsFiredTotal.Inc(rule.Name, rule.Type)
+		notifications = append(notifications, alertNotification{alert: alert, senders: am.sendersFor(rule)})
+	}
+	am.mu.Unlock()
+
+	am.dispatch(notifications)
+}
+
+func (am *alertManager) dispatch(notifications []alertNotification) {
+	for _, notification := range notifications {
+		for _, target := range notification.senders {
+			err := target.sender.Send(notification.alert)
+			result := "success"
+			if err != nil {
+				result = "failure"
+				subsystemLogger("alerts").Error("Failed to send alert notification", "notifier", target.name, "rule", notification.alert.Rule, "error", err)
+			}
+			alertNotificationsTotal.Inc(target.name, result)
+		}
+	}
+}
+
+func (am *alertManager) Test(name string, now time.Time) error {
+	am.mu.Lock()
+	sender, ok := am.notifiers[name]
+	am.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotifierNotFound, name)
+	}
+
+	return sender.Send(Alert{
+		Rule:     "test",
+		Type:     "test",
+		Severity: AlertWarning,
+		Status:   AlertFiring,
+		Subject:  name,
+		Summary:  "Test notification from Zen",
+		StartsAt: now,
+	})
+}
+
+func (am *alertManager) Active() []Alert {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	alerts := []Alert{}
+	for _, active := range am.active {
+		alerts = append(alerts, active.alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+	})
+	return alerts
+}
+
+func (am *alertManager) Recent() []Alert {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(am.recent))
+	for i := len(am.recent) - 1; i >= 0; i-- {
+		alerts = append(alerts, am.recent[i])
+	}
+	return alerts
+}
+
+func diskUsagePercent(path string) (float64, error) {
+	total, free, err := diskSpace(path)
+	if err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(total-free) / float64(total) * 100, nil
+}
+
+func fetchCertExpiry(address string) (time.Time, error) {
+	host, _, _ := net.SplitHostPort(address)
+	dialer := &net.Dialer{Timeout: notifierTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return time.Time{}, fmt.Errorf("no certificate presented by %s", address)
+	}
+	return certs[0].NotAfter, nil
+}
+
+func (au *AppUpdater) evaluateAlerts(now time.Time) {
+	setupData, err := au.loadSetupData()
+	if err != nil {
+		subsystemLogger("alerts").Error("Failed to load setup data", "error", err)
+		return
+	}
+	au.alerts.Configure(setupData.AlertRules, setupData.Notifiers)
+
+	processes := make(map[string]*ProcessInfo)
+	for _, app := range setupData.Apps {
+		if info, err := au.ProcessManager.GetProcess(app.Key); err == nil {
+			processes[app.Key] = info
+		}
+	}
+	au.alerts.Evaluate(now, processes)
+}
+
+func (au *AppUpdater) watchAlerts() {
+	ticker := time.NewTicker(alertEvalInterval)
+	defer ticker.Stop()
+
+	au.evaluateAlerts(time.Now())
+	for now := range ticker.C {
+		au.evaluateAlerts(now)
+	}
+}
+
+func (au *AppUpdater) TestNotifier(name string) error {
+	setupData, err := au.loadSetupData()
+	if err != nil {
+		return fmt.Errorf("failed to load setup data: %w", err)
+	}
+	au.alerts.Configure(setupData.AlertRules, setupData.Notifiers)
+	return au.alerts.Test(name, time.Now())
+}
+
+func (au *AppUpdater) Alerts() ([]Alert, []Alert) {
+	return au.alerts.Active(), au.alerts.Recent()
+}
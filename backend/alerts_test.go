@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingSender struct {
+	alerts []Alert
+}
+
+func (s *recordingSender) Send(alert Alert) error {
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func newTestAlertManager(rules ...AlertRule) (*alertManager, *recordingSender) {
+	am := newAlertManager()
+	am.Configure(rules, nil)
+	sender := &recordingSender{}
+	am.notifiers["test"] = sender
+	am.notifierOrder = []string{"test"}
+	return am, sender
+}
+
+func processesWith(appKey string, running bool, restarts int) map[string]*ProcessInfo {
+	return map[string]*ProcessInfo{
+		appKey: {AppKey: appKey, Processes: []ProcessState{{Type: "web", Running: running, Restarts: restarts}}},
+	}
+}
+
+func TestAppDownAlertFiresAfterDurationAndResolves(t *testing.T) {
+	am, sender := newTestAlertManager(AlertRule{Name: "down", Type: AlertAppDown, For: "30s"})
+	start := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	am.Evaluate(start, processesWith("org/app", false, 0))
+	am.Evaluate(start.Add(15*time.Second), processesWith("org/app", false, 0))
+	if len(sender.alerts) != 0 {
+		t.Fatalf("Expected no alert before duration elapses, got %+v", sender.alerts)
+	}
+
+	am.Evaluate(start.Add(30*time.Second), processesWith("org/app", false, 0))
+	am.Evaluate(start.Add(45*time.Second), processesWith("org/app", false, 0))
+	if len(sender.alerts) != 1 || sender.alerts[0].Status != AlertFiring || sender.alerts[0].Severity != AlertCritical || !sender.alerts[0].StartsAt.Equal(start) {
+		t.Fatalf("Expected one firing alert, got %+v", sender.alerts)
+	}
+	if active := am.Active(); len(active) != 1 || active[0].AppKey != "org/app" {
+		t.Errorf("Expected active alert, got %+v", active)
+	}
+
+	am.Evaluate(start.Add(time.Minute), processesWith("org/app", true, 1))
+	if len(sender.alerts) != 2 || sender.alerts[1].Status != AlertResolved || sender.alerts[1].EndsAt == nil {
+		t.Fatalf("Expected resolved notification, got %+v", sender.alerts)
+	}
+	if len(am.Active()) != 0 || len(am.Recent()) != 2 || am.Recent()[0].Status != AlertResolved {
+		t.Errorf("Expected history with resolution first, got %+v", am.Recent())
+	}
+}
+
+func TestAlertRepeatsWhileFiring(t *testing.T) {
+	am, sender := newTestAlertManager(AlertRule{Name: "down", Type: AlertAppDown, For: "0s", Repeat: "1m"})
+	start := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= 8; i++ {
+		am.Evaluate(start.Add(time.Duration(i)*15*time.Second), processesWith("org/app", false, 0))
+	}
+	if len(sender.alerts) != 3 {
+		t.Errorf("Expected initial notification and two repeats, got %d", len(sender.alerts))
+	}
+}
+
+func TestRestartStormAlert(t *testing.T) {
+	am, sender := newTestAlertManager(AlertRule{Name: "storm", Type: AlertRestartStorm, Restarts: 5, Window: "2m", Apps: []string{"org/app"}})
+	start := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	am.Evaluate(start, processesWith("org/app", true, 10))
+	am.Evaluate(start.Add(30*time.Second), processesWith("org/app", true, 13))
+	if len(sender.alerts) != 0 {
+		t.Fatalf("Expected baseline restarts to be ignored, got %+v", sender.alerts)
+	}
+
+	am.Evaluate(start.Add(time.Minute), processesWith("org/app", true, 15))
+	if len(sender.alerts) != 1 || !strings.Contains(sender.alerts[0].Summary, "restarted 5 times") {
+		t.Fatalf("Expected restart storm alert, got %+v", sender.alerts)
+	}
+
+	am.Evaluate(start.Add(4*time.Minute), processesWith("org/app", true, 15))
+	if len(sender.alerts) != 2 || sender.alerts[1].Status != AlertResolved {
+		t.Errorf("Expected storm to resolve once window passes, got %+v", sender.alerts)
+	}
+
+	am.Evaluate(start.Add(5*time.Minute), processesWith("org/other", true, 0))
+	am.Evaluate(start.Add(6*time.Minute), processesWith("org/other", true, 50))
+	if len(sender.alerts) != 2 {
+		t.Errorf("Expected rule to ignore other apps, got %+v", sender.alerts)
+	}
+}
+
+func TestDiskUsageAlertKeepsStateWhenUnreadable(t *testing.T) {
+	am, sender := newTestAlertManager(AlertRule{Name: "disk", Type: AlertDiskUsage, Threshold: 80, Path: "/data"})
+	usage, usageErr := 95.0, error(nil)
+	am.diskUsage = func(path string) (float64, error) {
+		if path != "/data" {
+			t.Errorf("Expected configured path, got %s", path)
+		}
+		return usage, usageErr
+	}
+	now := time.Now()
+
+	am.Evaluate(now, nil)
+	if len(sender.alerts) != 1 || sender.alerts[0].Subject != "/data" || sender.alerts[0].Severity != AlertWarning {
+		t.Fatalf("Expected disk alert, got %+v", sender.alerts)
+	}
+
+	usageErr = errors.New("statfs failed")
+	am.Evaluate(now.Add(time.Minute), nil)
+	if len(am.Active()) != 1 || len(sender.alerts) != 1 {
+		t.Errorf("Expected alert to stay active while usage is unknown, got %+v", sender.alerts)
+	}
+
+	usage, usageErr = 50, nil
+	am.Evaluate(now.Add(2*time.Minute), nil)
+	if len(sender.alerts) != 2 || sender.alerts[1].Status != AlertResolved {
+		t.Errorf("Expected disk alert to resolve, got %+v", sender.alerts)
+	}
+}
+
+func TestCertExpiryAlertAgainstTLSServer(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	address := strings.TrimPrefix(server.URL, "https://")
+	expiry, err := fetchCertExpiry(address)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !expiry.Equal(server.Certificate().NotAfter) {
+		t.Errorf("Expected expiry %v, got %v", server.Certificate().NotAfter, expiry)
+	}
+
+	days := int(time.Until(expiry).Hours()/24) + 1
+	am, sender := newTestAlertManager(
+		AlertRule{Name: "cert-soon", Type: AlertCertExpiry, Host: address, Days: days},
+		AlertRule{Name: "cert-ok", Type: AlertCertExpiry, Host: address, Days: 1},
+	)
+	am.Evaluate(time.Now(), nil)
+	if len(sender.alerts) != 1 || sender.alerts[0].Rule != "cert-soon" {
+		t.Errorf("Expected only the wide rule to fire, got %+v", sender.alerts)
+	}
+}
+
+func TestDeployFailedAlertReachesWebhook(t *testing.T) {
+	server, requests := newNotifierServer(t, http.StatusOK)
+
+	am := newAlertManager()
+	am.Configure(
+		[]AlertRule{
+			{Name: "deploys", Type: AlertDeployFailed, Notifiers: []string{"hook"}},
+			{Name: "other-app", Type: AlertDeployFailed, Apps: []string{"org/other"}},
+		},
+		[]Notifier{{Name: "hook", Type: NotifierWebhook, URL: server.URL}},
+	)
+
+	am.DeployFailed(Deployment{
+		ID:              "abc123",
+		AppKey:          "org/app",
+		Version:         "v2",
+		PreviousVersion: "v1",
+		Status:          DeploymentRolledBack,
+		Error:           "health check failed",
+		FinishedAt:      time.Now(),
+	})
+
+	select {
+	case req := <-requests:
+		var alert Alert
+		if err := json.Unmarshal(req.body, &alert); err != nil {
+			t.Fatalf("Expected alert JSON, got %s", req.body)
+		}
+		if alert.Rule != "deploys" || alert.Labels["version"] != "v2" || !strings.Contains(alert.Summary, "health check failed") {
+			t.Errorf("Unexpected alert %+v", alert)
+		}
+	default:
+		t.Fatal("Expected webhook to receive deploy failure")
+	}
+
+	if recent := am.Recent(); len(recent) != 1 || len(am.Active()) != 0 {
+		t.Errorf("Expected one recorded event and no active alerts, got %+v", recent)
+	}
+}
+
+func TestAlertManagerSkipsInvalidConfiguration(t *testing.T) {
+	am := newAlertManager()
+	am.Configure(
+		[]AlertRule{
+			{Name: "bad-type", Type: "cpu"},
+			{Name: "bad-cert", Type: AlertCertExpiry},
+			{Name: "bad-for", Type: AlertAppDown, For: "soon"},
+			{Name: "good", Type: AlertAppDown},
+		},
+		[]Notifier{{Name: "bad", Type: NotifierSlack}},
+	)
+
+	if len(am.rules) != 1 || am.rules[0].Name != "good" || len(am.notifiers) != 0 {
+		t.Errorf("Expected only valid rules and notifiers, got %+v %+v", am.rules, am.notifiers)
+	}
+	if err := am.Test("bad", time.Now()); !errors.Is(err, ErrNotifierNotFound) {
+		t.Errorf("Expected ErrNotifierNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var appUpdater *AppUpdater
+
+func handleCheckApp(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	deploymentID := appUpdater.CheckApp(app.Key, c.QueryBool("force"))
+
+	return c.Status(202).JSON(fiber.Map{
+		"status":       "accepted",
+		"deploymentId": deploymentID,
+	})
+}
+
+func handleListPendingReleases(c *fiber.Ctx) error {
+	return c.JSON(appUpdater.PendingReleases())
+}
+
+func handleApproveRelease(c *fiber.Ctx) error {
+	return handleReleaseDecision(c, appUpdater.ApproveRelease)
+}
+
+func handleRejectRelease(c *fiber.Ctx) error {
+	return handleReleaseDecision(c, appUpdater.RejectRelease)
+}
+
+func handleReleaseDecision(c *fiber.Ctx, decide func(appKey, version string) error) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	if err := decide(app.Key, c.Params("version")); err != nil {
+		if errors.Is(err, ErrNoPendingRelease) {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "No pending release with this version",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(204)
+}
+
+func handleListDeployments(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	deployments, err := appUpdater.Deployments(app.Key)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load deployment history",
+		})
+	}
+
+	return c.JSON(deployments)
+}
+
+func handleListProcesses(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	process, err := appUpdater.ProcessManager.GetProcess(app.Key)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App is not running",
+		})
+	}
+
+	return c.JSON(process)
+}
+
+func handleRunJob(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	run, err := appUpdater.RunJob(app.Key, c.Params("job"))
+	if errors.Is(err, ErrJobNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Job not found",
+		})
+	}
+	if errors.Is(err, ErrAppNotRunning) {
+		return c.Status(409).JSON(fiber.Map{
+			"error": "App is not running",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(202).JSON(run)
+}
+
+func handleListJobRuns(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	runs, err := appUpdater.JobRuns(app.Key)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load job history",
+		})
+	}
+
+	return c.JSON(runs)
+}
+
+func handleGetUsage(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	until := time.Now()
+	since := until.Add(-time.Hour)
+	for name, target := range map[string]*time.Time{"since": &since, "until": &until} {
+		if value := c.Query(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "Invalid " + name + " timestamp, expected RFC 3339",
+				})
+			}
+			*target = t
+		}
+	}
+
+	var step time.Duration
+	if value := c.Query("step"); value != "" {
+		step, err = time.ParseDuration(value)
+		if err != nil || step < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid step duration",
+			})
+		}
+	}
+
+	samples, err := appUpdater.Usage(app.Key, since, until, step)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load usage history",
+		})
+	}
+
+	return c.JSON(samples)
+}
+
+func handleUsageSummary(c *fiber.Ctx) error {
+	return c.JSON(appUpdater.UsageSummary())
+}
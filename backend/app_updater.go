@@ -5,18 +5,28 @@ import (
 	"archive/zip"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	githubAPIBaseURL = "https://api.github.com"
+	defaultAppsRoot  = "/opt/zen/apps"
+	schedulerTick    = 15 * time.Second
+)
+
+var ErrAppNotFound = errors.New("app not found")
+
 type GitHubRelease struct {
 	TagName string `json:"tag_name"`
 	Assets  []struct {
@@ -154,14 +164,16 @@ type githubDownloader struct {
 }
 
 func (gd *githubDownloader) GetLatestRelease(repo, token string) (*GitHubRelease, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", githubAPIBaseURL, repo)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	req.Header.Set("Accept", "application/vnd.github+json")
 
 	resp, err := gd.client.Do(req)
@@ -188,7 +200,9 @@ func (gd *githubDownloader) DownloadAsset(url, token string) (io.ReadCloser, err
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	resp, err := gd.client.Do(req)
 	if err != nil {
@@ -205,10 +219,32 @@ func (gd *githubDownloader) DownloadAsset(url, token string) (io.ReadCloser, err
 
 type AppUpdater struct {
 	setupFilePath  string
+	appsRoot       string
 	fs             FileSystemOps
 	extractor      ArchiveExtractor
-	downloader     GitHubDownloader
+	providers      map[string]ReleaseProvider
+	credentials    CredentialResolver
 	ProcessManager ProcessManager
+	appLocks       sync.Map
+	scheduleMu     sync.Mutex
+	nextChecks     map[string]time.Time
+	badSchedules   map[string]string
+	deploymentIDs  sync.Map
+	manifests      sync.Map
+	pending        *pendingReleases
+	approvals      *approvalStore
+	history        *deploymentHistory
+	jobs           *jobScheduler
+	jobHistory     *jobHistory
+	usage          *usageStore
+	hooks          HookRunner
+	healthChecker  HealthChecker
+	proxies        *proxyManager
+	status         updaterStatus
+	alerts         *alertManager
+	detected       sync.Map
+	installFailed  sync.Map
+	now            func() time.Time
 }
 
 func NewAppUpdater(
@@ -217,56 +253,294 @@ func NewAppUpdater(
 	extractor ArchiveExtractor,
 	downloader GitHubDownloader,
 	processManager ProcessManager,
+	credentials CredentialResolver,
 ) *AppUpdater {
 	return &AppUpdater{
-		setupFilePath:  setupFilePath,
-		fs:             fs,
-		extractor:      extractor,
-		downloader:     downloader,
+		setupFilePath: setupFilePath,
+		appsRoot:      defaultAppsRoot,
+		fs:            fs,
+		extractor:     extractor,
+		providers: map[string]ReleaseProvider{
+			"github": &githubProvider{downloader: downloader},
+		},
+		credentials:    credentials,
 		ProcessManager: processManager,
+		nextChecks:     make(map[string]time.Time),
+		badSchedules:   make(map[string]string),
+		pending:        newPendingReleases(fs, filepath.Join(filepath.Dir(setupFilePath), "pending.json")),
+		approvals:      newApprovalStore(fs, filepath.Join(filepath.Dir(setupFilePath), "approvals.json")),
+		history:        newDeploymentHistory(fs, filepath.Join(filepath.Dir(setupFilePath), "deployments.json")),
+		jobs:           newJobScheduler(),
+		jobHistory:     newJobHistory(fs, filepath.Join(filepath.Dir(setupFilePath), "jobs.json")),
+		usage:          newUsageStore(filepath.Join(filepath.Dir(setupFilePath), "usage")),
+		hooks:          &shellHookRunner{},
+		healthChecker:  &pollingHealthChecker{client: &http.Client{Timeout: 10 * time.Second}},
+		proxies:        newProxyManager(processManager),
+		alerts:         newAlertManager(),
+		now:            time.Now,
 	}
 }
 
-func NewDefaultAppUpdater(setupFilePath string) *AppUpdater {
+func NewDefaultAppUpdater(setupFilePath string, credentials CredentialResolver) *AppUpdater {
 	fs := &osFileSystem{}
-	httpClient := &http.Client{Timeout: 30 * time.Second}
-	return NewAppUpdater(
+	httpClient := &http.Client{Timeout: 30 * time.Second, Transport: newGitHubMetricsTransport(http.DefaultTransport)}
+	au := NewAppUpdater(
 		setupFilePath,
 		fs,
 		&archiveExtractorImpl{fs: fs},
 		&githubDownloader{client: httpClient},
 		NewProcessManager(),
+		credentials,
 	)
+	au.RegisterProvider("oci", &ociProvider{client: httpClient})
+	au.RegisterProvider("local", newLocalProvider(defaultInboxRoot))
+	au.RegisterProvider("git", newGitProvider(defaultGitCacheRoot, &archiveExtractorImpl{fs: fs}))
+	au.RegisterProvider("github-actions", &workflowProvider{client: httpClient, apiBaseURL: githubAPIBaseURL})
+	return au
+}
+
+func (au *AppUpdater) RegisterProvider(name string, provider ReleaseProvider) {
+	au.providers[name] = provider
 }
 
 func (au *AppUpdater) Start() {
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(schedulerTick)
 	defer ticker.Stop()
 
-	au.checkAndUpdateApps()
+	go au.collectUsage()
+	go au.watchAlerts()
 
-	for range ticker.C {
-		au.checkAndUpdateApps()
+	au.tick(time.Now())
+	for now := range ticker.C {
+		au.tick(now)
 	}
 }
 
-func (au *AppUpdater) checkAndUpdateApps() {
+func (au *AppUpdater) tick(now time.Time) {
+	au.status.beat(now)
+
 	setupData, err := au.loadSetupData()
+	au.status.scheduled(now, err)
 	if err != nil {
-		log.Printf("Failed to load setup data: %v", err)
+		subsystemLogger("updater").Error("Failed to load setup data", "op", "schedule", "error", err)
 		return
 	}
 
-	if setupData.GithubToken == "" {
-		log.Println("No GitHub token configured, skipping app updates")
+	au.scheduleDueApps(now, setupData)
+	au.scheduleDueJobs(now, setupData)
+}
+
+func (au *AppUpdater) scheduleDueApps(now time.Time, setupData *SetupData) {
+	logSinks.Configure(setupData.LogSinks)
+	events.Configure(setupData.EventWebhooks)
+
+	au.scheduleMu.Lock()
+	defer au.scheduleMu.Unlock()
+
+	configured := make(map[string]bool)
+	for _, app := range setupData.Apps {
+		configured[app.Key] = true
+
+		schedule, err := parseSchedule(app.Schedule)
+		if err != nil {
+			if au.badSchedules[app.Key] != app.Schedule {
+				subsystemLogger("updater").Warn("Invalid schedule, using default", "op", "schedule", "app", app.Key, "error", err)
+				au.badSchedules[app.Key] = app.Schedule
+			}
+			schedule = &intervalSchedule{interval: defaultPollInterval}
+		} else {
+			delete(au.badSchedules, app.Key)
+		}
+
+		next, scheduled := au.nextChecks[app.Key]
+		if scheduled && (schedule == nil || now.Before(next)) && !au.pendingWindowOpened(app, now) {
+			continue
+		}
+
+		au.nextChecks[app.Key] = nextCheckTime(schedule, app.Jitter, now)
+		go au.runScheduledCheck(app, setupData)
+	}
+
+	for key := range au.nextChecks {
+		if !configured[key] {
+			delete(au.nextChecks, key)
+			delete(au.badSchedules, key)
+		}
+	}
+}
+
+func (au *AppUpdater) pendingWindowOpened(app App, now time.Time) bool {
+	pending, ok := au.pending.Get(app.Key)
+	if !ok || pending.Reason != PendingOutsideWindow {
+		return false
+	}
+
+	open, err := inDeployWindow(app, now)
+	return err == nil && open
+}
+
+func nextCheckTime(schedule pollSchedule, jitterSpec string, now time.Time) time.Time {
+	if schedule == nil {
+		return time.Time{}
+	}
+
+	jitter := schedule.DefaultJitter()
+	if jitterSpec != "" {
+		if d, err := time.ParseDuration(jitterSpec); err == nil && d >= 0 {
+			jitter = d
+		}
+	}
+
+	next := schedule.Next(now)
+	if jitter > 0 {
+		next = next.Add(rand.N(jitter))
+	}
+	return next
+}
+
+func (au *AppUpdater) runScheduledCheck(app App, setupData *SetupData) {
+	lock := au.appLock(app.Key)
+	if !lock.TryLock() {
+		subsystemLogger("updater").Info("Update still in progress, skipping scheduled check", "op", "check", "app", app.Key)
 		return
 	}
+	defer lock.Unlock()
+
+	token, err := au.resolveToken(app, setupData)
+	if err != nil {
+		subsystemLogger("updater").Error("Failed to resolve credentials", "op", "check", "app", app.Key, "error", err)
+		return
+	}
+
+	if err := au.updateApp(app, token, false); err != nil {
+		subsystemLogger("updater").Error("Failed to update app", "op", "check", "app", app.Key, "error", err)
+	}
+}
+
+func (au *AppUpdater) appLock(appKey string) *sync.Mutex {
+	lock, _ := au.appLocks.LoadOrStore(appKey, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+func (au *AppUpdater) checkAndUpdateApp(app App, setupData *SetupData, force bool, deploymentID string) error {
+	token, err := au.resolveToken(app, setupData)
+	if err != nil {
+		return fmt.Errorf("failed to resolve credentials: %w", err)
+	}
+
+	lock := au.appLock(app.Key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if deploymentID != "" {
+		au.deploymentIDs.Store(app.Key, deploymentID)
+		defer au.deploymentIDs.Delete(app.Key)
+	}
+
+	return au.updateApp(app, token, force)
+}
+
+func (au *AppUpdater) CheckApp(appKey string, force bool) string {
+	deploymentID := newDeploymentID()
+	go func() {
+		if err := au.UpdateAppByKey(appKey, force, deploymentID); err != nil {
+			subsystemLogger("updater").Error("Failed to update app", "op", "check", "app", appKey, "deployId", deploymentID, "error", err)
+		}
+	}()
+	return deploymentID
+}
+
+func (au *AppUpdater) UpdateAppByKey(appKey string, force bool, deploymentID string) error {
+	setupData, err := au.loadSetupData()
+	if err != nil {
+		return fmt.Errorf("failed to load setup data: %w", err)
+	}
+
+	for _, app := range setupData.Apps {
+		if app.Key == appKey {
+			return au.checkAndUpdateApp(app, setupData, force, deploymentID)
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrAppNotFound, appKey)
+}
+
+func (au *AppUpdater) findAppByKey(appKey string) (*App, error) {
+	setupData, err := au.loadSetupData()
+	if err != nil {
+		return nil, err
+	}
 
 	for _, app := range setupData.Apps {
-		if err := au.updateApp(app, setupData.GithubToken); err != nil {
-			log.Printf("Failed to update app %s: %v", app.Key, err)
+		if app.Key == appKey {
+			return &app, nil
+		}
+	}
+
+	return nil, ErrAppNotFound
+}
+
+func (au *AppUpdater) FindAppBySlug(slug string) (*App, error) {
+	setupData, err := au.loadSetupData()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, app := range setupData.Apps {
+		if toSlug(app.Key) == slug {
+			return &app, nil
+		}
+	}
+
+	return nil, ErrAppNotFound
+}
+
+func (au *AppUpdater) TriggerUpdate(appKey string) {
+	go func() {
+		if err := au.UpdateAppByKey(appKey, false, ""); err != nil {
+			subsystemLogger("updater").Error("Failed to update app", "op", "trigger", "app", appKey, "error", err)
+		}
+	}()
+}
+
+func (au *AppUpdater) resolveToken(app App, setupData *SetupData) (string, error) {
+	if app.Credential != "" {
+		if au.credentials == nil {
+			return "", fmt.Errorf("no credential store configured")
+		}
+		if app.Provider == "git" {
+			if err := au.checkGitCredentialHost(app); err != nil {
+				return "", err
+			}
 		}
+		return au.credentials.Resolve(app.Credential)
 	}
+
+	switch app.Provider {
+	case "github", "github-actions":
+		return setupData.GithubToken, nil
+	case "git":
+		if gitRemoteMatchesHost(app.Key, githubHost) {
+			return setupData.GithubToken, nil
+		}
+	}
+	return "", nil
+}
+
+func (au *AppUpdater) checkGitCredentialHost(app App) error {
+	credential, err := au.credentials.Get(app.Credential)
+	if err != nil {
+		return err
+	}
+
+	host := credential.Host
+	if host == "" {
+		host = githubHost
+	}
+	if !gitRemoteMatchesHost(app.Key, host) {
+		return fmt.Errorf("credential %s is for %s and cannot be used for %s", app.Credential, host, app.Key)
+	}
+	return nil
 }
 
 func (au *AppUpdater) loadSetupData() (*SetupData, error) {
@@ -283,73 +557,375 @@ func (au *AppUpdater) loadSetupData() (*SetupData, error) {
 	return &setupData, nil
 }
 
-func (au *AppUpdater) updateApp(app App, githubToken string) error {
-	if app.Provider != "github" {
+func (au *AppUpdater) PendingReleases() []PendingRelease {
+	return au.pending.List()
+}
+
+func (au *AppUpdater) updateApp(app App, token string, force bool) (err error) {
+	updateChecksTotal.Inc(app.Key)
+	defer func() {
+		if err != nil {
+			updateCheckFailuresTotal.Inc(app.Key)
+		}
+		au.status.checkResult(app.Key, time.Now(), err)
+	}()
+
+	provider, ok := au.providers[app.Provider]
+	if !ok {
 		return fmt.Errorf("unsupported provider: %s", app.Provider)
 	}
 
-	release, err := au.downloader.GetLatestRelease(app.Key, githubToken)
+	release, err := provider.GetLatestRelease(app, token)
+	au.status.providerResult(app.Provider, time.Now(), err)
 	if err != nil {
 		return fmt.Errorf("failed to get latest release: %w", err)
 	}
 
-	slug := toSlug(app.Key)
-	releaseID := sanitizeReleaseID(release.TagName)
-	installPath := filepath.Join("/opt/zen/apps", fmt.Sprintf("%s-%s", slug, releaseID))
+	releaseID := sanitizeReleaseID(release.Version)
 
 	existingProcess, _ := au.ProcessManager.GetProcess(app.Key)
-	if existingProcess != nil && existingProcess.Version == releaseID {
-		if au.ProcessManager.IsRunning(app.Key) {
-			log.Printf("App %s version %s already running", app.Key, releaseID)
+	running := existingProcess != nil && au.ProcessManager.IsRunning(app.Key)
+	if running && existingProcess.Version == releaseID {
+		subsystemLogger("updater").Debug("Version already running", "op", "check", "app", app.Key, "version", releaseID)
+		au.pending.Delete(app.Key)
+		return nil
+	}
+	au.publishDetected(app, releaseID, existingProcess)
+
+	if app.Approval == ApprovalManual && !au.approvals.IsApproved(app.Key, releaseID) {
+		return au.stageForApproval(provider, app, release, releaseID, token, running)
+	}
+
+	if running && !force {
+		deferred, err := au.deferOutsideWindow(app, releaseID)
+		if err != nil {
+			return err
+		}
+		if deferred {
 			return nil
 		}
 	}
+	au.pending.Delete(app.Key)
+
+	if err := au.install(provider, app, release, releaseID, token); err != nil {
+		au.recordInstallFailure(app, releaseID, existingProcess, err)
+		return err
+	}
+	au.installFailed.Delete(app.Key)
+
+	return au.switchTo(app, releaseID)
+}
 
-	if _, err := au.fs.Stat(installPath); err != nil {
-		log.Printf("Installing app %s version %s", app.Key, releaseID)
+func (au *AppUpdater) recordInstallFailure(app App, releaseID string, existingProcess *ProcessInfo, err error) {
+	failure := releaseID + "\xff" + err.Error()
+	previous, repeated := au.installFailed.Swap(app.Key, failure)
+	repeated = repeated && previous == failure
 
-		if len(release.Assets) == 0 {
-			return fmt.Errorf("no assets found in release")
-		}
+	deploymentID := newDeploymentID()
+	if requested, ok := au.deploymentIDs.LoadAndDelete(app.Key); ok {
+		deploymentID = requested.(string)
+	} else if repeated {
+		return
+	}
+
+	now := au.now()
+	deployment := Deployment{
+		ID:         deploymentID,
+		AppKey:     app.Key,
+		Version:    releaseID,
+		Status:     DeploymentFailed,
+		Error:      err.Error(),
+		StartedAt:  now,
+		FinishedAt: now,
+	}
+	if existingProcess != nil {
+		deployment.PreviousVersion = existingProcess.Version
+	}
+
+	if recordErr := au.history.Record(deployment); recordErr != nil {
+		deploymentLogger(&deployment).Error("Failed to record deployment", "error", recordErr)
+	}
+	go au.alerts.DeployFailed(deployment)
+}
+
+func (au *AppUpdater) install(provider ReleaseProvider, app App, release *Release, releaseID, token string) error {
+	installPath := au.appInstallPath(app, releaseID)
+	if _, err := au.fs.Stat(installPath); err == nil {
+		return nil
+	}
+
+	logger := subsystemLogger("updater").With("op", "install", "app", app.Key, "version", releaseID)
+	logger.Info("Installing release")
+	events.Publish(Event{Type: EventDownloadStarted, AppKey: app.Key, Version: releaseID, Data: map[string]any{"asset": release.AssetName}})
+
+	stagingPath := filepath.Join(filepath.Dir(installPath), "."+filepath.Base(installPath)+".partial")
+	au.fs.RemoveAll(stagingPath)
+	if err := au.downloadAndExtract(provider, app, release, stagingPath, token); err != nil {
+		au.fs.RemoveAll(stagingPath)
+		return fmt.Errorf("failed to download and extract: %w", err)
+	}
+	if err := au.fs.Rename(stagingPath, installPath); err != nil {
+		au.fs.RemoveAll(stagingPath)
+		return fmt.Errorf("failed to move release into place: %w", err)
+	}
+	au.manifests.Delete(installPath)
+
+	logger.Info("Release installed")
+	events.Publish(Event{Type: EventInstalled, AppKey: app.Key, Version: releaseID, Data: map[string]any{"installPath": installPath}})
+	return nil
+}
+
+func (au *AppUpdater) publishDetected(app App, releaseID string, existing *ProcessInfo) {
+	if previous, ok := au.detected.Swap(app.Key, releaseID); ok && previous == releaseID {
+		return
+	}
+
+	data := map[string]any{"provider": app.Provider}
+	if existing != nil {
+		data["previousVersion"] = existing.Version
+	}
+	events.Publish(Event{Type: EventReleaseDetected, AppKey: app.Key, Version: releaseID, Data: data})
+}
+
+func (au *AppUpdater) switchTo(app App, releaseID string) error {
+	installPath := au.appInstallPath(app, releaseID)
+	base := app
+
+	app, manifestErr := au.appForRelease(base, installPath)
+	if len(processSpecs(app)) == 0 && manifestErr == nil {
+		return nil
+	}
+
+	existingProcess, _ := au.ProcessManager.GetProcess(app.Key)
 
-		asset := release.Assets[0]
-		if err := au.downloadAndExtract(asset.BrowserDownloadURL, asset.Name, installPath, githubToken); err != nil {
-			return fmt.Errorf("failed to download and extract: %w", err)
+	deploymentID := newDeploymentID()
+	if requested, ok := au.deploymentIDs.LoadAndDelete(app.Key); ok {
+		deploymentID = requested.(string)
+	}
+
+	deployment := &Deployment{
+		ID:        deploymentID,
+		AppKey:    app.Key,
+		Version:   releaseID,
+		StartedAt: au.now(),
+	}
+	if existingProcess != nil {
+		deployment.PreviousVersion = existingProcess.Version
+	}
+
+	env := appEnv(app, releaseID, deployment.PreviousVersion, installPath)
+	hooks := app.Hooks
+	if hooks == nil {
+		hooks = &Hooks{}
+	}
+
+	err := manifestErr
+	if err != nil {
+		deployment.Status = DeploymentAborted
+	} else {
+		err = au.deploy(base, app, deployment, existingProcess, hooks, installPath, env)
+	}
+	if err != nil {
+		if deployment.Status == "" {
+			deployment.Status = DeploymentFailed
 		}
+		deployment.Error = err.Error()
+		if hookErr := au.runHook(app, deployment, "on-failure", hooks.OnFailure, installPath, env); hookErr != nil {
+			deploymentLogger(deployment).Error("Hook failed", "hook", "on-failure", "error", hookErr)
+		}
+	}
+
+	deployment.FinishedAt = au.now()
+	deployDuration.Observe(deployment.FinishedAt.Sub(deployment.StartedAt).Seconds(), app.Key, deployment.Status)
+	if recordErr := au.history.Record(*deployment); recordErr != nil {
+		deploymentLogger(deployment).Error("Failed to record deployment", "error", recordErr)
+	}
+	if deployment.Status == DeploymentRolledBack {
+		events.Publish(deploymentEvent(EventRolledBack, deployment, map[string]any{"restoredVersion": deployment.PreviousVersion, "error": deployment.Error}))
+	}
+	if err != nil {
+		go au.alerts.DeployFailed(*deployment)
+	}
+
+	return err
+}
 
-		log.Printf("Successfully installed app %s version %s", app.Key, releaseID)
+func (au *AppUpdater) appForRelease(app App, installPath string) (App, error) {
+	manifest, err := au.cachedManifest(installPath)
+	if err != nil {
+		return app, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if manifest == nil {
+		return app, nil
 	}
 
-	if app.Command != "" {
-		if existingProcess != nil && existingProcess.Version != releaseID {
-			log.Printf("Stopping old version of %s (version %s)", app.Key, existingProcess.Version)
-			if err := au.ProcessManager.Stop(app.Key); err != nil {
-				log.Printf("Failed to stop old version: %v", err)
+	if err := checkZenVersion(manifest.ZenVersion, buildVersion); err != nil {
+		return app, err
+	}
+	return applyManifest(app, manifest), nil
+}
+
+func (au *AppUpdater) deploy(base, app App, deployment *Deployment, existingProcess *ProcessInfo, hooks *Hooks, installPath string, env []string) error {
+	releaseID := deployment.Version
+	logger := deploymentLogger(deployment)
+
+	if err := au.runHook(app, deployment, "pre-start", hooks.PreStart, installPath, env); err != nil {
+		deployment.Status = DeploymentAborted
+		return err
+	}
+
+	specs := processSpecs(app)
+	if err := au.proxies.Ensure(app.Key, specs); err != nil {
+		deployment.Status = DeploymentAborted
+		return err
+	}
+
+	switching := existingProcess != nil && existingProcess.Version != releaseID
+	if switching {
+		previous, err := au.appForRelease(base, existingProcess.InstallPath)
+		if err != nil {
+			previous = base
+		}
+		if previous.Hooks != nil {
+			stopEnv := appEnv(previous, existingProcess.Version, "", existingProcess.InstallPath)
+			if err := au.runHook(previous, deployment, "pre-stop", previous.Hooks.PreStop, existingProcess.InstallPath, stopEnv); err != nil {
+				logger.Warn("Hook failed", "hook", "pre-stop", "error", err)
 			}
 		}
 
-		log.Printf("Starting app %s version %s", app.Key, releaseID)
-		if err := au.ProcessManager.Start(app.Key, releaseID, app.Command, installPath); err != nil {
+		logger.Info("Rolling upgrade", "from", existingProcess.Version)
+		if err := au.ProcessManager.Upgrade(app.Key, releaseID, installPath, specs, env, au.replicaReady(app, specs)); err != nil {
+			au.restartPrevious(base, existingProcess)
+			deployment.Status = DeploymentRolledBack
+			return fmt.Errorf("failed to upgrade app: %w", err)
+		}
+	} else {
+		logger.Info("Starting app")
+		if err := au.ProcessManager.Start(app.Key, releaseID, installPath, specs, env); err != nil {
 			return fmt.Errorf("failed to start app: %w", err)
 		}
-		log.Printf("App %s version %s started successfully", app.Key, releaseID)
+	}
+	events.Publish(deploymentEvent(EventStarted, deployment, map[string]any{"previousVersion": deployment.PreviousVersion}))
+
+	if app.HealthCheck != nil {
+		err := au.healthChecker.Check(*app.HealthCheck, installPath, env)
+		recordHealthCheck(app.Key, "deploy", err)
+		if err != nil {
+			logger.Error("Health check failed", "error", err)
+			if err := au.ProcessManager.Stop(app.Key); err != nil {
+				logger.Error("Failed to stop unhealthy version", "error", err)
+			}
+			if switching {
+				au.restartPrevious(base, existingProcess)
+				deployment.Status = DeploymentRolledBack
+			}
+			return err
+		}
+		events.Publish(deploymentEvent(EventHealthPassed, deployment, nil))
+	}
+	logger.Info("App started")
+
+	deployment.Status = DeploymentSucceeded
+	if err := au.runHook(app, deployment, "post-start", hooks.PostStart, installPath, env); err != nil {
+		logger.Warn("Hook failed", "hook", "post-start", "error", err)
+		deployment.Error = err.Error()
 	}
 
 	return nil
 }
 
-func (au *AppUpdater) downloadAndExtract(url, filename, installPath, token string) error {
+func (au *AppUpdater) restartPrevious(base App, previous *ProcessInfo) {
+	logger := subsystemLogger("deploy").With("op", "rollback", "app", base.Key, "version", previous.Version)
+	logger.Warn("Restarting previous version")
+
+	app, err := au.appForRelease(base, previous.InstallPath)
+	if err != nil {
+		logger.Warn("Ignoring manifest of previous version", "error", err)
+	}
+
+	specs := processSpecs(app)
+	if err := au.proxies.Ensure(app.Key, specs); err != nil {
+		logger.Error("Failed to restore proxies of previous version", "error", err)
+	}
+
+	env := appEnv(app, previous.Version, "", previous.InstallPath)
+	if err := au.ProcessManager.Start(app.Key, previous.Version, previous.InstallPath, specs, env); err != nil {
+		logger.Error("Failed to restart previous version", "error", err)
+	}
+}
+
+func (au *AppUpdater) replicaReady(app App, specs []ProcessSpec) func(ProcessState) error {
+	return func(state ProcessState) error {
+		if state.Port == 0 {
+			return nil
+		}
+
+		check := HealthCheck{TCP: fmt.Sprintf("127.0.0.1:%d", state.Port)}
+		for _, spec := range specs {
+			if spec.Type == state.Type && spec.HealthPath != "" {
+				check = HealthCheck{HTTP: fmt.Sprintf("http://127.0.0.1:%d%s", state.Port, spec.HealthPath)}
+			}
+		}
+		if app.HealthCheck != nil {
+			check.Timeout = app.HealthCheck.Timeout
+			check.Interval = app.HealthCheck.Interval
+		}
+
+		err := au.healthChecker.Check(check, "", nil)
+		recordHealthCheck(app.Key, "replica", err)
+		return err
+	}
+}
+
+func (au *AppUpdater) Deployments(appKey string) ([]Deployment, error) {
+	return au.history.List(appKey)
+}
+
+func (au *AppUpdater) appInstallPath(app App, releaseID string) string {
+	return filepath.Join(au.appsRoot, fmt.Sprintf("%s-%s", toSlug(app.Key), releaseID))
+}
+
+func (au *AppUpdater) deferOutsideWindow(app App, releaseID string) (bool, error) {
+	now := au.now()
+	open, err := inDeployWindow(app, now)
+	if err != nil {
+		return false, fmt.Errorf("invalid deploy window: %w", err)
+	}
+	if open {
+		return false, nil
+	}
+
+	pending := PendingRelease{
+		AppKey:     app.Key,
+		Version:    releaseID,
+		Reason:     PendingOutsideWindow,
+		DetectedAt: now,
+	}
+	if next, err := nextDeployWindow(app, now); err == nil {
+		pending.ApplyAfter = &next
+	}
+	au.pending.Set(pending)
+
+	subsystemLogger("updater").Info("Outside deploy window, deferring upgrade", "op", "check", "app", app.Key, "version", releaseID)
+	return true, nil
+}
+
+func (au *AppUpdater) downloadAndExtract(provider ReleaseProvider, app App, release *Release, installPath, token string) error {
+	if installer, ok := provider.(releaseInstaller); ok {
+		return installer.InstallRelease(app, release, installPath, token)
+	}
+
 	if err := au.fs.MkdirAll(installPath, 0755); err != nil {
 		return err
 	}
 
-	body, err := au.downloader.DownloadAsset(url, token)
+	body, err := provider.DownloadRelease(app, release, token)
 	if err != nil {
 		return err
 	}
 	defer body.Close()
 
-	tmpFile := filepath.Join(installPath, filename)
+	tmpFile := filepath.Join(installPath, release.AssetName)
 	out, err := au.fs.Create(tmpFile)
 	if err != nil {
 		return err
@@ -8,7 +8,9 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 type mockFileSystemUpdater struct {
@@ -64,6 +66,39 @@ func (m *mockFileSystemUpdater) Remove(name string) error {
 	return nil
 }
 
+func (m *mockFileSystemUpdater) RemoveAll(path string) error {
+	for name := range m.files {
+		if name == path || strings.HasPrefix(name, path+"/") {
+			delete(m.files, name)
+		}
+	}
+	for name := range m.directories {
+		if name == path || strings.HasPrefix(name, path+"/") {
+			delete(m.directories, name)
+		}
+	}
+	return nil
+}
+
+func (m *mockFileSystemUpdater) Rename(oldpath, newpath string) error {
+	if !m.directories[oldpath] {
+		return os.ErrNotExist
+	}
+	for name, data := range m.files {
+		if strings.HasPrefix(name, oldpath+"/") {
+			delete(m.files, name)
+			m.files[newpath+strings.TrimPrefix(name, oldpath)] = data
+		}
+	}
+	for name := range m.directories {
+		if name == oldpath || strings.HasPrefix(name, oldpath+"/") {
+			delete(m.directories, name)
+			m.directories[newpath+strings.TrimPrefix(name, oldpath)] = true
+		}
+	}
+	return nil
+}
+
 func (m *mockFileSystemUpdater) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return nil, errors.New("not implemented in mock")
 }
@@ -71,10 +106,10 @@ func (m *mockFileSystemUpdater) OpenFile(name string, flag int, perm os.FileMode
 type mockProcessManager struct {
 	processes map[string]*ProcessInfo
 	started   []struct {
-		appKey  string
-		version string
-		command string
-		workDir string
+		appKey    string
+		version   string
+		workDir   string
+		processes []ProcessSpec
 	}
 	stopped []string
 }
@@ -82,25 +117,24 @@ type mockProcessManager struct {
 func newMockProcessManager() *mockProcessManager {
 	return &mockProcessManager{
 		processes: make(map[string]*ProcessInfo),
-		started:   make([]struct {
-			appKey  string
-			version string
-			command string
-			workDir string
+		started: make([]struct {
+			appKey    string
+			version   string
+			workDir   string
+			processes []ProcessSpec
 		}, 0),
 		stopped: make([]string, 0),
 	}
 }
 
-func (m *mockProcessManager) Start(appKey, version, command, workDir string) error {
+func (m *mockProcessManager) Start(appKey, version, workDir string, processes []ProcessSpec, env []string) error {
 	m.started = append(m.started, struct {
-		appKey  string
-		version string
-		command string
-		workDir string
-	}{appKey, version, command, workDir})
+		appKey    string
+		version   string
+		workDir   string
+		processes []ProcessSpec
+	}{appKey, version, workDir, processes})
 	m.processes[appKey] = &ProcessInfo{
-		PID:         12345,
 		AppKey:      appKey,
 		Version:     version,
 		InstallPath: workDir,
@@ -108,6 +142,20 @@ func (m *mockProcessManager) Start(appKey, version, command, workDir string) err
 	return nil
 }
 
+func (m *mockProcessManager) Upgrade(appKey, version, workDir string, processes []ProcessSpec, env []string, ready func(ProcessState) error) error {
+	for _, spec := range processes {
+		if err := ready(ProcessState{Type: spec.Type, Version: version}); err != nil {
+			return err
+		}
+	}
+	return m.Start(appKey, version, workDir, processes, env)
+}
+
+func (m *mockProcessManager) SubscribeLogs(appKey string) (<-chan LogLine, func()) {
+	ch := make(chan LogLine)
+	return ch, func() { close(ch) }
+}
+
 func (m *mockProcessManager) Stop(appKey string) error {
 	m.stopped = append(m.stopped, appKey)
 	delete(m.processes, appKey)
@@ -189,6 +237,7 @@ func TestLoadSetupData(t *testing.T) {
 		&mockArchiveExtractor{},
 		&mockGitHubDownloader{},
 		newMockProcessManager(),
+		nil,
 	)
 
 	result, err := updater.loadSetupData()
@@ -272,3 +321,209 @@ func TestSanitizeReleaseID(t *testing.T) {
 		}
 	}
 }
+
+type mockCredentialResolver struct {
+	tokens map[string]string
+	hosts  map[string]string
+}
+
+func (m *mockCredentialResolver) Get(name string) (*Credential, error) {
+	token, ok := m.tokens[name]
+	if !ok {
+		return nil, fmt.Errorf("credential %s not found", name)
+	}
+	return &Credential{Name: name, Host: m.hosts[name], Secret: token}, nil
+}
+
+func (m *mockCredentialResolver) Resolve(name string) (string, error) {
+	if token, ok := m.tokens[name]; ok {
+		return token, nil
+	}
+	return "", fmt.Errorf("credential %s not found", name)
+}
+
+func TestResolveToken(t *testing.T) {
+	updater := NewAppUpdater(
+		"/opt/zen/data/setup.json",
+		newMockFileSystem(),
+		&mockArchiveExtractor{},
+		&mockGitHubDownloader{},
+		newMockProcessManager(),
+		&mockCredentialResolver{
+			tokens: map[string]string{"deploy": "per-app", "gitlab": "bot:glpat"},
+			hosts:  map[string]string{"gitlab": "gitlab.com"},
+		},
+	)
+
+	tests := []struct {
+		app      App
+		setup    SetupData
+		expected string
+	}{
+		{App{Provider: "github", Key: "org/public"}, SetupData{}, ""},
+		{App{Provider: "github", Key: "org/legacy"}, SetupData{GithubToken: "global"}, "global"},
+		{App{Provider: "github", Key: "org/private", Credential: "deploy"}, SetupData{GithubToken: "global"}, "per-app"},
+		{App{Provider: "oci", Key: "ghcr.io/org/app"}, SetupData{GithubToken: "global"}, ""},
+		{App{Provider: "git", Key: "https://github.com/org/app.git"}, SetupData{GithubToken: "global"}, "global"},
+		{App{Provider: "git", Key: "https://gitlab.com/org/app.git"}, SetupData{GithubToken: "global"}, ""},
+		{App{Provider: "git", Key: "https://evil.example/github.com/org/app.git"}, SetupData{GithubToken: "global"}, ""},
+		{App{Provider: "git", Key: "https://github.com.evil.example/org/app.git"}, SetupData{GithubToken: "global"}, ""},
+		{App{Provider: "git", Key: "https://gitlab.com/org/app.git", Credential: "gitlab"}, SetupData{}, "bot:glpat"},
+	}
+
+	for _, tt := range tests {
+		token, err := updater.resolveToken(tt.app, &tt.setup)
+		if err != nil {
+			t.Fatalf("resolveToken(%s) returned error: %v", tt.app.Key, err)
+		}
+		if token != tt.expected {
+			t.Errorf("resolveToken(%s) = '%s', expected '%s'", tt.app.Key, token, tt.expected)
+		}
+	}
+
+	if _, err := updater.resolveToken(App{Provider: "github", Credential: "missing"}, &SetupData{}); err == nil {
+		t.Error("Expected error for missing credential, got nil")
+	}
+	if _, err := updater.resolveToken(App{Provider: "git", Key: "https://github.com/org/app.git", Credential: "gitlab"}, &SetupData{}); err == nil {
+		t.Error("Expected error for credential used on another host, got nil")
+	}
+}
+
+func TestScheduleDueApps(t *testing.T) {
+	fs := newMockFileSystem()
+	data, _ := json.Marshal(SetupData{
+		Apps: []App{
+			{Provider: "none", Key: "org/polled", Schedule: "10m", Jitter: "0s"},
+			{Provider: "none", Key: "org/pushed", Schedule: "webhook-only"},
+		},
+	})
+	fs.files["/opt/zen/data/setup.json"] = data
+
+	updater := NewAppUpdater(
+		"/opt/zen/data/setup.json",
+		fs,
+		&mockArchiveExtractor{},
+		&mockGitHubDownloader{},
+		newMockProcessManager(),
+		nil,
+	)
+
+	now := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	setup, _ := updater.loadSetupData()
+	updater.scheduleDueApps(now, setup)
+
+	if next := updater.nextChecks["org/polled"]; !next.Equal(now.Add(10 * time.Minute)) {
+		t.Errorf("Expected org/polled next check at %s, got %s", now.Add(10*time.Minute), next)
+	}
+
+	if next, ok := updater.nextChecks["org/pushed"]; !ok || !next.IsZero() {
+		t.Errorf("Expected org/pushed to be checked once and never polled, got %s", next)
+	}
+
+	updater.scheduleDueApps(now.Add(time.Hour), setup)
+
+	if next := updater.nextChecks["org/polled"]; !next.Equal(now.Add(70 * time.Minute)) {
+		t.Errorf("Expected org/polled rescheduled at %s, got %s", now.Add(70*time.Minute), next)
+	}
+
+	if next := updater.nextChecks["org/pushed"]; !next.IsZero() {
+		t.Errorf("Expected org/pushed to stay unscheduled, got %s", next)
+	}
+}
+
+func TestUpdateAppDefersUpgradeOutsideDeployWindow(t *testing.T) {
+	fs := newMockFileSystem()
+	fs.directories["/opt/zen/apps/org-app-1.1.0"] = true
+
+	processManager := newMockProcessManager()
+	processManager.Start("org/app", "1.0.0", "/opt/zen/apps/org-app-1.0.0", []ProcessSpec{{Type: "web", Command: "./run"}}, nil)
+
+	updater := NewAppUpdater("/opt/zen/data/setup.json", fs, &mockArchiveExtractor{}, newTestReleaseDownloader("v1.1.0"), processManager, nil)
+	updater.now = func() time.Time { return time.Date(2025, 3, 14, 14, 0, 0, 0, time.UTC) }
+
+	app := App{
+		Provider:      "github",
+		Key:           "org/app",
+		Command:       "./run",
+		Timezone:      "UTC",
+		DeployWindows: []DeployWindow{{Days: []string{"mon"}, Start: "02:00", End: "04:00"}},
+	}
+
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process.Version != "1.0.0" {
+		t.Errorf("Expected version 1.0.0 to keep running, got %s", process.Version)
+	}
+
+	pending := updater.PendingReleases()
+	if len(pending) != 1 || pending[0].Version != "1.1.0" || pending[0].ApplyAfter == nil {
+		t.Fatalf("Expected pending release 1.1.0 with apply time, got %+v", pending)
+	}
+
+	if err := updater.updateApp(app, "", true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process.Version != "1.1.0" {
+		t.Errorf("Expected forced deploy of 1.1.0, got %s", process.Version)
+	}
+
+	if len(updater.PendingReleases()) != 0 {
+		t.Errorf("Expected pending release to be cleared after deploy")
+	}
+}
+
+func TestUpdateAppByKeyUsesRequestedDeploymentID(t *testing.T) {
+	updater, _ := newHookTestUpdater(&mockHookRunner{})
+	data, _ := json.Marshal(SetupData{Apps: []App{{Provider: "github", Key: "org/app", Command: "./run"}}})
+	updater.fs.(*mockFileSystemUpdater).files["/opt/zen/data/setup.json"] = data
+
+	if err := updater.UpdateAppByKey("org/app", false, "requested-id"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	deployments, _ := updater.Deployments("org/app")
+	if len(deployments) != 1 || deployments[0].ID != "requested-id" {
+		t.Errorf("Expected deployment with requested id, got %+v", deployments)
+	}
+	if _, ok := updater.deploymentIDs.Load("org/app"); ok {
+		t.Error("Expected requested deployment id to be cleared")
+	}
+}
+
+func TestUpdateAppRecordsFailedInstall(t *testing.T) {
+	fs := newMockFileSystem()
+	processManager := newMockProcessManager()
+	processManager.Start("org/app", "1.0.0", "/opt/zen/apps/org-app-1.0.0", []ProcessSpec{{Type: "web", Command: "./run"}}, nil)
+	app := App{Provider: "github", Key: "org/app", Command: "./run"}
+	data, _ := json.Marshal(SetupData{Apps: []App{app}})
+	fs.files["/opt/zen/data/setup.json"] = data
+	updater := NewAppUpdater("/opt/zen/data/setup.json", fs, &mockArchiveExtractor{}, newTestReleaseDownloader("v1.2.0"), processManager, nil)
+
+	if err := updater.UpdateAppByKey("org/app", true, "requested-id"); err == nil {
+		t.Fatal("Expected install error, got nil")
+	}
+
+	deployments, _ := updater.Deployments("org/app")
+	if len(deployments) != 1 {
+		t.Fatalf("Expected one deployment, got %+v", deployments)
+	}
+	deployment := deployments[0]
+	if deployment.ID != "requested-id" || deployment.Status != DeploymentFailed || deployment.Version != "1.2.0" || deployment.PreviousVersion != "1.0.0" || deployment.Error == "" {
+		t.Errorf("Expected failed install to be recorded, got %+v", deployment)
+	}
+
+	for poll := 1; poll <= 2; poll++ {
+		updater.updateApp(app, "", false)
+	}
+	if deployments, _ := updater.Deployments("org/app"); len(deployments) != 1 {
+		t.Errorf("Expected repeated install failure to be recorded once, got %d deployments", len(deployments))
+	}
+
+	updater.UpdateAppByKey("org/app", true, "retry-id")
+	if deployments, _ := updater.Deployments("org/app"); len(deployments) != 2 {
+		t.Errorf("Expected requested retry to be recorded, got %d deployments", len(deployments))
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+const (
+	ApprovalAuto            = "auto"
+	ApprovalManual          = "manual"
+	PendingAwaitingApproval = "awaiting-approval"
+)
+
+var ErrNoPendingRelease = errors.New("no pending release")
+
+type appApprovals struct {
+	Approved string   `json:"approved,omitempty"`
+	Rejected []string `json:"rejected,omitempty"`
+}
+
+type approvalStore struct {
+	fs       FileSystemOps
+	filePath string
+	mu       sync.Mutex
+}
+
+func newApprovalStore(fs FileSystemOps, filePath string) *approvalStore {
+	return &approvalStore{
+		fs:       fs,
+		filePath: filePath,
+	}
+}
+
+func (s *approvalStore) IsApproved(appKey, version string) bool {
+	return s.Approved(appKey) == version
+}
+
+func (s *approvalStore) IsRejected(appKey, version string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approvals, err := s.load()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(approvals[appKey].Rejected, version)
+}
+
+func (s *approvalStore) Approved(appKey string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approvals, err := s.load()
+	if err != nil {
+		subsystemLogger("approval").Error("Failed to load approvals", "app", appKey, "error", err)
+		return ""
+	}
+	return approvals[appKey].Approved
+}
+
+func (s *approvalStore) Approve(appKey, version string) error {
+	return s.update(appKey, func(a *appApprovals) {
+		a.Approved = version
+		a.Rejected = slices.DeleteFunc(a.Rejected, func(v string) bool { return v == version })
+	})
+}
+
+func (s *approvalStore) Reject(appKey, version string) error {
+	return s.update(appKey, func(a *appApprovals) {
+		if !slices.Contains(a.Rejected, version) {
+			a.Rejected = append(a.Rejected, version)
+		}
+	})
+}
+
+func (s *approvalStore) update(appKey string, fn func(a *appApprovals)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approvals, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	entry := approvals[appKey]
+	fn(&entry)
+	approvals[appKey] = entry
+
+	if err := s.fs.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(approvals, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return s.fs.WriteFile(s.filePath, jsonData, 0600)
+}
+
+func (s *approvalStore) load() (map[string]appApprovals, error) {
+	data, err := s.fs.ReadFile(s.filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(map[string]appApprovals), nil
+		}
+		return nil, err
+	}
+
+	approvals := make(map[string]appApprovals)
+	if err := json.Unmarshal(data, &approvals); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+func (au *AppUpdater) stageForApproval(provider ReleaseProvider, app App, release *Release, releaseID, token string, running bool) error {
+	if au.approvals.IsRejected(app.Key, releaseID) {
+		subsystemLogger("approval").Info("Release was rejected, not staging it", "op", "stage", "app", app.Key, "version", releaseID)
+	} else {
+		if err := au.install(provider, app, release, releaseID, token); err != nil {
+			return err
+		}
+
+		au.pending.Set(PendingRelease{
+			AppKey:     app.Key,
+			Version:    releaseID,
+			Reason:     PendingAwaitingApproval,
+			DetectedAt: au.now(),
+		})
+		subsystemLogger("approval").Info("Release staged and awaiting approval", "op", "stage", "app", app.Key, "version", releaseID)
+	}
+
+	if running {
+		return nil
+	}
+
+	approved := au.approvals.Approved(app.Key)
+	if approved == "" {
+		return nil
+	}
+
+	if _, err := au.fs.Stat(au.appInstallPath(app, approved)); err != nil {
+		return fmt.Errorf("approved version %s is no longer installed", approved)
+	}
+
+	return au.switchTo(app, approved)
+}
+
+func (au *AppUpdater) ApproveRelease(appKey, version string) error {
+	app, err := au.findAppByKey(appKey)
+	if err != nil {
+		return err
+	}
+
+	lock := au.appLock(appKey)
+	lock.Lock()
+	defer lock.Unlock()
+
+	pending, ok := au.pending.Get(appKey)
+	if !ok || pending.Reason != PendingAwaitingApproval || pending.Version != version {
+		return ErrNoPendingRelease
+	}
+
+	if err := au.approvals.Approve(appKey, version); err != nil {
+		return err
+	}
+	au.pending.Delete(appKey)
+
+	subsystemLogger("approval").Info("Release approved", "op", "approve", "app", appKey, "version", version)
+	return au.switchTo(*app, version)
+}
+
+func (au *AppUpdater) RejectRelease(appKey, version string) error {
+	lock := au.appLock(appKey)
+	lock.Lock()
+	defer lock.Unlock()
+
+	pending, ok := au.pending.Get(appKey)
+	if !ok || pending.Reason != PendingAwaitingApproval || pending.Version != version {
+		return ErrNoPendingRelease
+	}
+
+	if err := au.approvals.Reject(appKey, version); err != nil {
+		return err
+	}
+	au.pending.Delete(appKey)
+
+	subsystemLogger("approval").Info("Release rejected", "op", "reject", "app", appKey, "version", version)
+	return nil
+}
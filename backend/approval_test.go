@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func newTestReleaseDownloader(tag string) *mockGitHubDownloader {
+	downloader := &mockGitHubDownloader{release: &GitHubRelease{TagName: tag}}
+	downloader.release.Assets = append(downloader.release.Assets, struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	}{Name: "app.tar.gz"})
+	return downloader
+}
+
+func newApprovalTestUpdater(fs *mockFileSystemUpdater, processManager ProcessManager, tag string) (*AppUpdater, App) {
+	app := App{Provider: "github", Key: "org/app", Command: "./run", Approval: ApprovalManual}
+	data, _ := json.Marshal(SetupData{Apps: []App{app}})
+	fs.files["/opt/zen/data/setup.json"] = data
+	fs.directories["/opt/zen/apps/org-app-1.0.0"] = true
+	fs.directories["/opt/zen/apps/org-app-1.1.0"] = true
+
+	updater := NewAppUpdater("/opt/zen/data/setup.json", fs, &mockArchiveExtractor{}, newTestReleaseDownloader(tag), processManager, nil)
+	return updater, app
+}
+
+func TestManualApprovalStagesAndDeploysOnApprove(t *testing.T) {
+	fs := newMockFileSystem()
+	processManager := newMockProcessManager()
+	processManager.Start("org/app", "1.0.0", "/opt/zen/apps/org-app-1.0.0", []ProcessSpec{{Type: "web", Command: "./run"}}, nil)
+	updater, app := newApprovalTestUpdater(fs, processManager, "v1.1.0")
+
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process.Version != "1.0.0" {
+		t.Errorf("Expected 1.0.0 to keep running until approval, got %s", process.Version)
+	}
+
+	pending := updater.PendingReleases()
+	if len(pending) != 1 || pending[0].Reason != PendingAwaitingApproval || pending[0].Version != "1.1.0" {
+		t.Fatalf("Expected 1.1.0 awaiting approval, got %+v", pending)
+	}
+
+	if err := updater.ApproveRelease("org/app", "1.2.0"); !errors.Is(err, ErrNoPendingRelease) {
+		t.Errorf("Expected no pending release error for unknown version, got %v", err)
+	}
+
+	if err := updater.ApproveRelease("org/app", "1.1.0"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process.Version != "1.1.0" {
+		t.Errorf("Expected 1.1.0 after approval, got %s", process.Version)
+	}
+
+	if !updater.approvals.IsApproved("org/app", "1.1.0") {
+		t.Error("Expected approval to be persisted")
+	}
+}
+
+func TestManualApprovalRejectedReleaseIsNotRestaged(t *testing.T) {
+	fs := newMockFileSystem()
+	processManager := newMockProcessManager()
+	processManager.Start("org/app", "1.0.0", "/opt/zen/apps/org-app-1.0.0", []ProcessSpec{{Type: "web", Command: "./run"}}, nil)
+	updater, app := newApprovalTestUpdater(fs, processManager, "v1.1.0")
+
+	updater.updateApp(app, "", false)
+
+	if err := updater.RejectRelease("org/app", "1.1.0"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	updater.updateApp(app, "", false)
+
+	if len(updater.PendingReleases()) != 0 {
+		t.Errorf("Expected rejected release not to be staged again, got %+v", updater.PendingReleases())
+	}
+}
+
+func TestManualApprovalStartsApprovedVersionAfterRestart(t *testing.T) {
+	fs := newMockFileSystem()
+	processManager := newMockProcessManager()
+	updater, app := newApprovalTestUpdater(fs, processManager, "v1.1.0")
+	updater.approvals.Approve("org/app", "1.0.0")
+
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	process, err := processManager.GetProcess("org/app")
+	if err != nil || process.Version != "1.0.0" {
+		t.Fatalf("Expected approved 1.0.0 to be started, got %+v (%v)", process, err)
+	}
+
+	if pending := updater.PendingReleases(); len(pending) != 1 || pending[0].Version != "1.1.0" {
+		t.Errorf("Expected 1.1.0 awaiting approval, got %+v", pending)
+	}
+}
+
+func TestPendingApprovalSurvivesRestart(t *testing.T) {
+	fs := newMockFileSystem()
+	processManager := newMockProcessManager()
+	processManager.Start("org/app", "1.0.0", "/opt/zen/apps/org-app-1.0.0", []ProcessSpec{{Type: "web", Command: "./run"}}, nil)
+	updater, app := newApprovalTestUpdater(fs, processManager, "v1.1.0")
+
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	restarted, _ := newApprovalTestUpdater(fs, processManager, "v1.1.0")
+	if pending := restarted.PendingReleases(); len(pending) != 1 || pending[0].Version != "1.1.0" {
+		t.Fatalf("Expected 1.1.0 awaiting approval after restart, got %+v", pending)
+	}
+
+	if err := restarted.RejectRelease("org/app", "1.1.0"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pending := restarted.PendingReleases(); len(pending) != 0 {
+		t.Errorf("Expected rejected release to be removed, got %+v", pending)
+	}
+	if _, ok := fs.files["/opt/zen/data/pending.json"]; !ok {
+		t.Error("Expected pending releases to be stored next to approvals.json")
+	}
+}
+
+func TestManualApprovalDoesNotStageFailedInstall(t *testing.T) {
+	fs := newMockFileSystem()
+	processManager := newMockProcessManager()
+	processManager.Start("org/app", "1.0.0", "/opt/zen/apps/org-app-1.0.0", []ProcessSpec{{Type: "web", Command: "./run"}}, nil)
+	updater, app := newApprovalTestUpdater(fs, processManager, "v1.2.0")
+
+	for poll := 1; poll <= 2; poll++ {
+		if err := updater.updateApp(app, "", false); err == nil {
+			t.Errorf("Expected install error on poll %d, got nil", poll)
+		}
+	}
+
+	if pending := updater.PendingReleases(); len(pending) != 0 {
+		t.Errorf("Expected failed install not to be staged, got %+v", pending)
+	}
+}
@@ -121,3 +121,18 @@ func handleLogout(c *fiber.Ctx) error {
 		"message": "Logged out",
 	})
 }
+
+func isAuthenticated(c *fiber.Ctx) bool {
+	_, err := validateJWT(c.Cookies("auth_token"))
+	return err == nil
+}
+
+func requireAuth(c *fiber.Ctx) error {
+	if !isAuthenticated(c) {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	return c.Next()
+}
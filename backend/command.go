@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const maxCommandOutput = 64 * 1024
+
+type cappedOutput struct {
+	limit int
+	head  []byte
+	tail  []byte
+	total int64
+}
+
+func (o *cappedOutput) Write(p []byte) (int, error) {
+	written := len(p)
+	o.total += int64(written)
+
+	half := o.limit / 2
+	if room := half - len(o.head); room > 0 {
+		n := min(room, len(p))
+		o.head = append(o.head, p[:n]...)
+		p = p[n:]
+	}
+
+	o.tail = append(o.tail, p...)
+	if len(o.tail) > o.limit {
+		o.tail = append(o.tail[:0], o.tail[len(o.tail)-half:]...)
+	}
+	return written, nil
+}
+
+func (o *cappedOutput) Bytes() []byte {
+	tail := o.tail
+	if half := o.limit / 2; len(tail) > half {
+		tail = tail[len(tail)-half:]
+	}
+
+	output := append([]byte(nil), o.head...)
+	if dropped := o.total - int64(len(o.head)+len(tail)); dropped > 0 {
+		output = append(output, fmt.Sprintf("\n...(%d bytes truncated)...\n", dropped)...)
+	}
+	return append(output, tail...)
+}
+
+func runCommand(command, workDir string, env []string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = workDir
+	cmd.Env = env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	output := &cappedOutput{limit: maxCommandOutput}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output.Bytes(), fmt.Errorf("command timed out after %s", timeout)
+	}
+	return output.Bytes(), err
+}
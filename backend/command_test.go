@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCommandCapsOutputKeepingHeadAndTail(t *testing.T) {
+	output, err := runCommand("echo START; head -c 1000000 /dev/zero | tr '\\0' x; echo; echo END", t.TempDir(), os.Environ(), 10*time.Second)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	text := string(output)
+	if len(output) > maxCommandOutput+64 {
+		t.Errorf("Expected output capped near %d bytes, got %d", maxCommandOutput, len(output))
+	}
+	if !strings.HasPrefix(text, "START\n") || !strings.HasSuffix(text, "\nEND\n") || !strings.Contains(text, "bytes truncated") {
+		t.Errorf("Expected head, truncation marker and tail, got %q...%q", text[:20], text[len(text)-20:])
+	}
+}
+
+func TestCappedOutputKeepsShortOutput(t *testing.T) {
+	output := &cappedOutput{limit: 16}
+	output.Write([]byte("hello "))
+	output.Write([]byte("world"))
+
+	if got := string(output.Bytes()); got != "hello world" {
+		t.Errorf("Expected 'hello world', got %q", got)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+var credentialService *CredentialService
+
+func handleListCredentials(c *fiber.Ctx) error {
+	credentials, err := credentialService.List()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load credentials",
+		})
+	}
+
+	return c.JSON(credentials)
+}
+
+func handleSaveCredential(c *fiber.Ctx) error {
+	var credential Credential
+	if err := c.BodyParser(&credential); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := credentialService.Save(credential); err != nil {
+		return c.Status(422).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.SendStatus(204)
+}
+
+func handleDeleteCredential(c *fiber.Ctx) error {
+	if err := credentialService.Delete(c.Params("name")); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to delete credential",
+		})
+	}
+
+	return c.SendStatus(204)
+}
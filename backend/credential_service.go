@@ -0,0 +1,315 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Credential struct {
+	Name           string `json:"name"`
+	Provider       string `json:"provider"`
+	Host           string `json:"host,omitempty"`
+	Username       string `json:"username,omitempty"`
+	AppID          string `json:"appId,omitempty"`
+	InstallationID string `json:"installationId,omitempty"`
+	Secret         string `json:"secret,omitempty"`
+}
+
+type CredentialResolver interface {
+	Get(name string) (*Credential, error)
+	Resolve(name string) (string, error)
+}
+
+type CredentialValidator interface {
+	Validate(credential Credential) error
+}
+
+type CredentialService struct {
+	fs          FileSystemOps
+	validator   CredentialValidator
+	tokenSource InstallationTokenSource
+	filePath    string
+	key         []byte
+	mu          sync.Mutex
+}
+
+func NewCredentialService(
+	fs FileSystemOps,
+	validator CredentialValidator,
+	tokenSource InstallationTokenSource,
+	filePath string,
+	key []byte,
+) *CredentialService {
+	return &CredentialService{
+		fs:          fs,
+		validator:   validator,
+		tokenSource: tokenSource,
+		filePath:    filePath,
+		key:         key,
+	}
+}
+
+func NewDefaultCredentialService(encryptionKey string) (*CredentialService, error) {
+	key, err := base64.StdEncoding.DecodeString(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second, Transport: newGitHubMetricsTransport(http.DefaultTransport)}
+	tokenSource := newGitHubAppTokenSource(httpClient, githubAPIBaseURL)
+	return NewCredentialService(
+		&osFileSystem{},
+		&credentialValidatorImpl{client: httpClient, githubAPIBaseURL: githubAPIBaseURL, tokenSource: tokenSource},
+		tokenSource,
+		"/opt/zen/data/credentials.json",
+		key,
+	), nil
+}
+
+func (s *CredentialService) List() ([]Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credentials, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range credentials {
+		credentials[i].Secret = ""
+	}
+	return credentials, nil
+}
+
+func (s *CredentialService) Save(credential Credential) error {
+	if credential.Name == "" || credential.Provider == "" || credential.Secret == "" {
+		return fmt.Errorf("name, provider and secret are required")
+	}
+
+	if err := s.validator.Validate(credential); err != nil {
+		return fmt.Errorf("credential validation failed: %w", err)
+	}
+
+	encrypted, err := s.encrypt(credential.Secret)
+	if err != nil {
+		return err
+	}
+	credential.Secret = encrypted
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credentials, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range credentials {
+		if credentials[i].Name == credential.Name {
+			credentials[i] = credential
+			replaced = true
+		}
+	}
+	if !replaced {
+		credentials = append(credentials, credential)
+	}
+
+	return s.store(credentials)
+}
+
+func (s *CredentialService) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credentials, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	remaining := credentials[:0]
+	for _, credential := range credentials {
+		if credential.Name != name {
+			remaining = append(remaining, credential)
+		}
+	}
+
+	return s.store(remaining)
+}
+
+func (s *CredentialService) Get(name string) (*Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credentials, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, credential := range credentials {
+		if credential.Name == name {
+			secret, err := s.decrypt(credential.Secret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt credential %s: %w", name, err)
+			}
+			credential.Secret = secret
+			return &credential, nil
+		}
+	}
+
+	return nil, fmt.Errorf("credential %s not found", name)
+}
+
+func (s *CredentialService) Resolve(name string) (string, error) {
+	credential, err := s.Get(name)
+	if err != nil {
+		return "", err
+	}
+
+	if credential.Provider == "github-app" {
+		return s.tokenSource.Token(*credential)
+	}
+	return credential.token(), nil
+}
+
+func (c Credential) token() string {
+	if c.Username != "" {
+		return c.Username + ":" + c.Secret
+	}
+	return c.Secret
+}
+
+func (s *CredentialService) load() ([]Credential, error) {
+	data, err := s.fs.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Credential{}, nil
+		}
+		return nil, err
+	}
+
+	var credentials []Credential
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (s *CredentialService) store(credentials []Credential) error {
+	if err := s.fs.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return s.fs.WriteFile(s.filePath, jsonData, 0600)
+}
+
+func (s *CredentialService) encrypt(plaintext string) (string, error) {
+	gcm, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *CredentialService) decrypt(ciphertext string) (string, error) {
+	gcm, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func (s *CredentialService) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type credentialValidatorImpl struct {
+	client           HTTPClient
+	githubAPIBaseURL string
+	tokenSource      InstallationTokenSource
+}
+
+func (v *credentialValidatorImpl) Validate(credential Credential) error {
+	switch credential.Provider {
+	case "github-app":
+		_, err := v.tokenSource.Token(credential)
+		return err
+	case "github", "github-actions":
+		return v.validateGitHub(credential)
+	case "git":
+		if credential.Host == "" || strings.EqualFold(credential.Host, githubHost) {
+			return v.validateGitHub(credential)
+		}
+		if u, err := url.Parse("https://" + credential.Host); err != nil || u.Host != credential.Host || u.User != nil {
+			return fmt.Errorf("git credential host must be a host name such as gitlab.com, got %q", credential.Host)
+		}
+	case "oci":
+		if credential.Host == "" {
+			return fmt.Errorf("host is required for oci credentials")
+		}
+		return (&ociProvider{client: v.client}).ping(credential.Host, credential.token())
+	}
+	return nil
+}
+
+func (v *credentialValidatorImpl) validateGitHub(credential Credential) error {
+	req, err := http.NewRequest("GET", v.githubAPIBaseURL+"/rate_limit", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+credential.Secret)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub rejected token with status %d", resp.StatusCode)
+	}
+	return nil
+}
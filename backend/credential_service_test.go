@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockCredentialValidator struct {
+	err error
+}
+
+func (m *mockCredentialValidator) Validate(credential Credential) error {
+	return m.err
+}
+
+type mockInstallationTokenSource struct{}
+
+func (m *mockInstallationTokenSource) Token(credential Credential) (string, error) {
+	return "ghs_installation_" + credential.AppID, nil
+}
+
+func newTestCredentialService(fs *mockFileSystemUpdater, validator CredentialValidator) *CredentialService {
+	return NewCredentialService(fs, validator, &mockInstallationTokenSource{}, "/test/credentials.json", bytes.Repeat([]byte{1}, 32))
+}
+
+func TestCredentialServiceEncryptsSecrets(t *testing.T) {
+	fs := newMockFileSystem()
+	service := newTestCredentialService(fs, &mockCredentialValidator{})
+
+	err := service.Save(Credential{Name: "gh", Provider: "github", Secret: "ghp_secret"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if bytes.Contains(fs.files["/test/credentials.json"], []byte("ghp_secret")) {
+		t.Error("Expected secret to be encrypted at rest")
+	}
+
+	token, err := service.Resolve("gh")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token != "ghp_secret" {
+		t.Errorf("Expected token 'ghp_secret', got '%s'", token)
+	}
+
+	credentials, _ := service.List()
+	if len(credentials) != 1 || credentials[0].Secret != "" {
+		t.Errorf("Expected one credential without secret, got %+v", credentials)
+	}
+}
+
+func TestCredentialServiceRejectsInvalidToken(t *testing.T) {
+	fs := newMockFileSystem()
+	service := newTestCredentialService(fs, &mockCredentialValidator{err: errors.New("bad token")})
+
+	if err := service.Save(Credential{Name: "gh", Provider: "github", Secret: "bad"}); err == nil {
+		t.Error("Expected validation error, got nil")
+	}
+
+	if _, ok := fs.files["/test/credentials.json"]; ok {
+		t.Error("Expected invalid credential not to be stored")
+	}
+}
+
+func TestCredentialServiceResolvesUsernameAndDelete(t *testing.T) {
+	service := newTestCredentialService(newMockFileSystem(), &mockCredentialValidator{})
+
+	service.Save(Credential{Name: "registry", Provider: "oci", Host: "ghcr.io", Username: "bot", Secret: "pw"})
+
+	token, err := service.Resolve("registry")
+	if err != nil || token != "bot:pw" {
+		t.Errorf("Expected token 'bot:pw', got '%s' (%v)", token, err)
+	}
+
+	service.Delete("registry")
+	if _, err := service.Resolve("registry"); err == nil {
+		t.Error("Expected error resolving deleted credential, got nil")
+	}
+}
+
+func TestCredentialValidatorGitHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	validator := &credentialValidatorImpl{client: server.Client(), githubAPIBaseURL: server.URL}
+
+	if err := validator.Validate(Credential{Provider: "github", Secret: "good"}); err != nil {
+		t.Errorf("Expected valid token, got %v", err)
+	}
+
+	if err := validator.Validate(Credential{Provider: "github", Secret: "bad"}); err == nil {
+		t.Error("Expected invalid token error, got nil")
+	}
+}
+
+func TestCredentialValidatorGitHosts(t *testing.T) {
+	validator := &credentialValidatorImpl{}
+
+	if err := validator.Validate(Credential{Provider: "git", Host: "gitlab.com", Username: "bot", Secret: "glpat"}); err != nil {
+		t.Errorf("Expected plain host to be accepted, got %v", err)
+	}
+	if err := validator.Validate(Credential{Provider: "git", Host: "git.example.com:8443", Secret: "token"}); err != nil {
+		t.Errorf("Expected host with port to be accepted, got %v", err)
+	}
+
+	for _, host := range []string{"https://gitlab.com", "gitlab.com/org", "user@gitlab.com"} {
+		if err := validator.Validate(Credential{Provider: "git", Host: host, Secret: "token"}); err == nil {
+			t.Errorf("Expected host %q to be rejected, got nil", host)
+		}
+	}
+}
+
+func TestCredentialServiceResolvesGitHubAppToken(t *testing.T) {
+	service := newTestCredentialService(newMockFileSystem(), &mockCredentialValidator{})
+
+	service.Save(Credential{Name: "app", Provider: "github-app", AppID: "123", Secret: "pem"})
+
+	token, err := service.Resolve("app")
+	if err != nil || token != "ghs_installation_123" {
+		t.Errorf("Expected installation token, got '%s' (%v)", token, err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type DeployWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start,omitempty"`
+	End   string   `json:"end,omitempty"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun":       time.Sunday,
+	"sunday":    time.Sunday,
+	"mon":       time.Monday,
+	"monday":    time.Monday,
+	"tue":       time.Tuesday,
+	"tuesday":   time.Tuesday,
+	"wed":       time.Wednesday,
+	"wednesday": time.Wednesday,
+	"thu":       time.Thursday,
+	"thursday":  time.Thursday,
+	"fri":       time.Friday,
+	"friday":    time.Friday,
+	"sat":       time.Saturday,
+	"saturday":  time.Saturday,
+}
+
+func deployWindowLocation(app App) (*time.Location, error) {
+	if app.Timezone == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(app.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %s: %w", app.Timezone, err)
+	}
+	return loc, nil
+}
+
+func inDeployWindow(app App, now time.Time) (bool, error) {
+	if len(app.DeployWindows) == 0 {
+		return true, nil
+	}
+
+	loc, err := deployWindowLocation(app)
+	if err != nil {
+		return false, err
+	}
+	return windowsOpen(app.DeployWindows, now.In(loc))
+}
+
+func windowsOpen(windows []DeployWindow, t time.Time) (bool, error) {
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, window := range windows {
+		start, end, err := window.minutes()
+		if err != nil {
+			return false, err
+		}
+
+		today, err := window.includesDay(t.Weekday())
+		if err != nil {
+			return false, err
+		}
+
+		if start < end {
+			if today && minute >= start && minute < end {
+				return true, nil
+			}
+			continue
+		}
+
+		yesterday, err := window.includesDay((t.Weekday() + 6) % 7)
+		if err != nil {
+			return false, err
+		}
+
+		if (today && minute >= start) || (yesterday && minute < end) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func nextDeployWindow(app App, now time.Time) (time.Time, error) {
+	loc, err := deployWindowLocation(app)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t := now.In(loc).Truncate(time.Minute)
+	if open, err := windowsOpen(app.DeployWindows, t); err != nil || open || len(app.DeployWindows) == 0 {
+		return t, err
+	}
+
+	var next time.Time
+	for _, window := range app.DeployWindows {
+		start, _, err := window.minutes()
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		for day := 0; day <= 7; day++ {
+			opening := time.Date(t.Year(), t.Month(), t.Day()+day, start/60, start%60, 0, 0, loc)
+			if !opening.After(t) {
+				continue
+			}
+			included, err := window.includesDay(opening.Weekday())
+			if err != nil {
+				return time.Time{}, err
+			}
+			if included {
+				if next.IsZero() || opening.Before(next) {
+					next = opening
+				}
+				break
+			}
+		}
+	}
+
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("no deploy window opens within a week")
+	}
+	return next, nil
+}
+
+func (w DeployWindow) minutes() (int, int, error) {
+	start, err := parseClock(w.Start, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err := parseClock(w.End, 24*60)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if start == end {
+		return 0, 0, fmt.Errorf("deploy window %s-%s is empty", w.Start, w.End)
+	}
+	return start, end, nil
+}
+
+func (w DeployWindow) includesDay(day time.Weekday) (bool, error) {
+	if len(w.Days) == 0 {
+		return true, nil
+	}
+
+	for _, name := range w.Days {
+		weekday, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return false, fmt.Errorf("invalid deploy window day %q", name)
+		}
+		if weekday == day {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func parseClock(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
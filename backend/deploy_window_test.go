@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInDeployWindow(t *testing.T) {
+	app := App{
+		Timezone: "Europe/Berlin",
+		DeployWindows: []DeployWindow{
+			{Days: []string{"mon", "tue", "wed", "thu"}, Start: "22:00", End: "06:00"},
+			{Days: []string{"Saturday"}},
+		},
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	tests := []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2025, 3, 14, 14, 0, 0, 0, berlin), false},
+		{time.Date(2025, 3, 17, 22, 30, 0, 0, berlin), true},
+		{time.Date(2025, 3, 18, 5, 59, 0, 0, berlin), true},
+		{time.Date(2025, 3, 18, 6, 0, 0, 0, berlin), false},
+		{time.Date(2025, 3, 21, 3, 0, 0, 0, berlin), true},
+		{time.Date(2025, 3, 21, 23, 0, 0, 0, berlin), false},
+		{time.Date(2025, 3, 15, 12, 0, 0, 0, berlin), true},
+		{time.Date(2025, 3, 17, 20, 30, 0, 0, time.UTC), false},
+		{time.Date(2025, 3, 17, 21, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		open, err := inDeployWindow(app, tt.at)
+		if err != nil {
+			t.Fatalf("inDeployWindow(%s) returned error: %v", tt.at, err)
+		}
+		if open != tt.expected {
+			t.Errorf("inDeployWindow(%s) = %v, expected %v", tt.at, open, tt.expected)
+		}
+	}
+}
+
+func TestInDeployWindowWithoutWindows(t *testing.T) {
+	if open, _ := inDeployWindow(App{}, time.Now()); !open {
+		t.Error("Expected apps without windows to always be deployable")
+	}
+}
+
+func TestNextDeployWindow(t *testing.T) {
+	app := App{
+		Timezone:      "UTC",
+		DeployWindows: []DeployWindow{{Days: []string{"mon"}, Start: "02:00", End: "04:00"}},
+	}
+
+	next, err := nextDeployWindow(app, time.Date(2025, 3, 14, 14, 7, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if expected := time.Date(2025, 3, 17, 2, 0, 0, 0, time.UTC); !next.Equal(expected) {
+		t.Errorf("Expected next window at %s, got %s", expected, next)
+	}
+}
+
+func TestInDeployWindowInvalidConfig(t *testing.T) {
+	for _, app := range []App{
+		{Timezone: "Mars/Olympus", DeployWindows: []DeployWindow{{}}},
+		{DeployWindows: []DeployWindow{{Days: []string{"someday"}}}},
+		{DeployWindows: []DeployWindow{{Days: []string{"monkey"}}}},
+		{DeployWindows: []DeployWindow{{Days: []string{"Saturnday"}}}},
+		{DeployWindows: []DeployWindow{{Days: []string{"\u212A"}}}},
+		{DeployWindows: []DeployWindow{{Start: "25:00"}}},
+	} {
+		if _, err := inDeployWindow(app, time.Now()); err == nil {
+			t.Errorf("Expected error for %+v, got nil", app)
+		}
+	}
+}
+
+func TestNextDeployWindowMatchesMinuteScan(t *testing.T) {
+	apps := []App{
+		{DeployWindows: []DeployWindow{{Days: []string{"fri"}, Start: "22:00", End: "02:00"}}},
+		{Timezone: "America/New_York", DeployWindows: []DeployWindow{{Days: []string{"sat", "sun"}, Start: "01:30", End: "03:00"}}},
+		{DeployWindows: []DeployWindow{{Days: []string{"tue"}, Start: "09:00", End: "10:00"}, {Days: []string{"mon"}, Start: "23:00", End: "01:00"}}},
+	}
+
+	for _, app := range apps {
+		for _, now := range []time.Time{
+			time.Date(2025, 3, 14, 14, 7, 30, 0, time.UTC),
+			time.Date(2025, 3, 15, 23, 59, 0, 0, time.UTC),
+			time.Date(2025, 3, 18, 9, 30, 0, 0, time.UTC),
+		} {
+			expected := now.Truncate(time.Minute)
+			for open, _ := inDeployWindow(app, expected); !open; open, _ = inDeployWindow(app, expected) {
+				expected = expected.Add(time.Minute)
+			}
+
+			next, err := nextDeployWindow(app, now)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !next.Equal(expected) {
+				t.Errorf("Expected next window at %s for %+v from %s, got %s", expected, app.DeployWindows, now, next)
+			}
+		}
+	}
+}
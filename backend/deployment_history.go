@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	DeploymentSucceeded  = "succeeded"
+	DeploymentFailed     = "failed"
+	DeploymentAborted    = "aborted"
+	DeploymentRolledBack = "rolled-back"
+
+	deploymentHistoryLimit = 50
+)
+
+type HookResult struct {
+	Name       string    `json:"name"`
+	Command    string    `json:"command"`
+	ExitCode   int       `json:"exitCode"`
+	Output     string    `json:"output"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+type Deployment struct {
+	ID              string       `json:"id"`
+	AppKey          string       `json:"appKey"`
+	Version         string       `json:"version"`
+	PreviousVersion string       `json:"previousVersion,omitempty"`
+	Status          string       `json:"status"`
+	Error           string       `json:"error,omitempty"`
+	StartedAt       time.Time    `json:"startedAt"`
+	FinishedAt      time.Time    `json:"finishedAt"`
+	Hooks           []HookResult `json:"hooks,omitempty"`
+}
+
+type deploymentHistory struct {
+	fs       FileSystemOps
+	filePath string
+	mu       sync.Mutex
+}
+
+func newDeploymentHistory(fs FileSystemOps, filePath string) *deploymentHistory {
+	return &deploymentHistory{
+		fs:       fs,
+		filePath: filePath,
+	}
+}
+
+func newDeploymentID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func deploymentLogger(deployment *Deployment) *slog.Logger {
+	return subsystemLogger("deploy").With("op", "deploy", "app", deployment.AppKey, "version", deployment.Version, "deployId", deployment.ID)
+}
+
+func (h *deploymentHistory) Record(deployment Deployment) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history, err := h.load()
+	if err != nil {
+		return err
+	}
+
+	deployments := append([]Deployment{deployment}, history[deployment.AppKey]...)
+	if len(deployments) > deploymentHistoryLimit {
+		deployments = deployments[:deploymentHistoryLimit]
+	}
+	history[deployment.AppKey] = deployments
+
+	if err := h.fs.MkdirAll(filepath.Dir(h.filePath), 0755); err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return h.fs.WriteFile(h.filePath, jsonData, 0600)
+}
+
+func (h *deploymentHistory) List(appKey string) ([]Deployment, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := history[appKey]
+	if deployments == nil {
+		deployments = []Deployment{}
+	}
+	return deployments, nil
+}
+
+func (h *deploymentHistory) load() (map[string][]Deployment, error) {
+	data, err := h.fs.ReadFile(h.filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(map[string][]Deployment), nil
+		}
+		return nil, err
+	}
+
+	history := make(map[string][]Deployment)
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func eventFilterFromQuery(c *fiber.Ctx) (EventFilter, error) {
+	var filter EventFilter
+	for _, value := range strings.Split(c.Query("type"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if !slices.Contains(eventTypes, value) {
+			return filter, fmt.Errorf("unknown event type %q", value)
+		}
+		filter.Types = append(filter.Types, value)
+	}
+	for _, value := range strings.Split(c.Query("app"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			filter.Apps = append(filter.Apps, value)
+		}
+	}
+	return filter, nil
+}
+
+func handleListEvents(c *fiber.Ctx) error {
+	filter, err := eventFilterFromQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	since, _ := strconv.ParseUint(c.Query("since"), 10, 64)
+	limit := min(max(c.QueryInt("limit", 100), 0), eventHistorySize)
+	return c.JSON(events.Since(since, filter, limit))
+}
+
+func handleStreamEvents(c *fiber.Ctx) error {
+	filter, err := eventFilterFromQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	live, unsubscribe := events.Subscribe()
+
+	var backlog []Event
+	lastID := c.Get("Last-Event-ID", c.Query("since"))
+	if id, err := strconv.ParseUint(lastID, 10, 64); err == nil {
+		backlog = events.Since(id, filter, 0)
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		var sent uint64
+		for _, event := range backlog {
+			writeEvent(w, event)
+			sent = event.ID
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(logStreamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case event, ok := <-live:
+				if !ok {
+					return
+				}
+				if event.ID <= sent || !filter.Matches(event) {
+					continue
+				}
+				writeEvent(w, event)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeEvent(w *bufio.Writer, event Event) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+func handleListEventWebhooks(c *fiber.Ctx) error {
+	return c.JSON(events.WebhookStatus())
+}
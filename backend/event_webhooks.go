@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	eventSignatureHeader    = "X-Zen-Signature-256"
+	defaultWebhookAttempts  = 5
+	defaultWebhookRetryBase = time.Second
+	maxWebhookRetryDelay    = time.Minute
+	webhookQueueSize        = 256
+	webhookTimeout          = 10 * time.Second
+)
+
+type EventWebhook struct {
+	Name        string            `json:"name"`
+	URL         string            `json:"url"`
+	Secret      string            `json:"secret,omitempty"`
+	Events      []string          `json:"events,omitempty"`
+	Apps        []string          `json:"apps,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	MaxAttempts int               `json:"maxAttempts,omitempty"`
+}
+
+type EventWebhookStatus struct {
+	Name          string    `json:"name"`
+	URL           string    `json:"url"`
+	Delivered     int64     `json:"delivered"`
+	Failed        int64     `json:"failed"`
+	Dropped       int64     `json:"dropped"`
+	Pending       int       `json:"pending"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastDelivered time.Time `json:"lastDelivered,omitempty"`
+}
+
+type webhookStatusError struct {
+	status int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.status)
+}
+
+func (e *webhookStatusError) retryable() bool {
+	return e.status >= 500 || e.status == http.StatusTooManyRequests || e.status == http.StatusRequestTimeout
+}
+
+func signEventPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookDelivery struct {
+	config      EventWebhook
+	client      HTTPClient
+	maxAttempts int
+	retryBase   time.Duration
+	queue       chan Event
+	stop        chan struct{}
+	done        chan struct{}
+
+	mu            sync.Mutex
+	delivered     int64
+	failed        int64
+	dropped       int64
+	lastError     string
+	lastDelivered time.Time
+}
+
+func newWebhookDelivery(config EventWebhook) (*webhookDelivery, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("event webhook requires an http(s) url")
+	}
+	for _, eventType := range config.Events {
+		if !slices.Contains(eventTypes, eventType) {
+			return nil, fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+
+	return &webhookDelivery{
+		config:      config,
+		client:      &http.Client{Timeout: webhookTimeout},
+		maxAttempts: positiveOr(config.MaxAttempts, defaultWebhookAttempts),
+		retryBase:   defaultWebhookRetryBase,
+		queue:       make(chan Event, webhookQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+func (d *webhookDelivery) accepts(event Event) bool {
+	return EventFilter{Types: d.config.Events, Apps: d.config.Apps}.Matches(event)
+}
+
+func (d *webhookDelivery) enqueue(event Event) {
+	select {
+	case d.queue <- event:
+	default:
+		d.mu.Lock()
+		d.dropped++
+		d.mu.Unlock()
+		webhookDeliveriesTotal.Inc(d.config.Name, "dropped")
+	}
+}
+
+func (d *webhookDelivery) handOver(next *webhookDelivery) {
+	for {
+		select {
+		case event := <-d.queue:
+			if next.accepts(event) {
+				next.enqueue(event)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (d *webhookDelivery) run() {
+	defer close(d.done)
+
+	for {
+		select {
+		case event := <-d.queue:
+			d.deliver(event)
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+func (d *webhookDelivery) deliver(event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = d.send(event, body, attempt)
+		if err == nil {
+			d.mu.Lock()
+			d.delivered++
+			d.lastDelivered = time.Now()
+			d.lastError = ""
+			d.mu.Unlock()
+			webhookDeliveriesTotal.Inc(d.config.Name, "success")
+			return
+		}
+
+		d.mu.Lock()
+		d.lastError = err.Error()
+		d.mu.Unlock()
+
+		var statusErr *webhookStatusError
+		if attempt >= d.maxAttempts || (errors.As(err, &statusErr) && !statusErr.retryable()) {
+			break
+		}
+
+		delay := min(d.retryBase<<(attempt-1), maxWebhookRetryDelay)
+		select {
+		case <-time.After(delay):
+		case <-d.stop:
+			return
+		}
+	}
+
+	d.mu.Lock()
+	d.failed++
+	d.mu.Unlock()
+	webhookDeliveriesTotal.Inc(d.config.Name, "failure")
+	subsystemLogger("events").Error("Failed to deliver event webhook", "webhook", d.config.Name, "event", event.Type, "app", event.AppKey, "error", err)
+}
+
+func (d *webhookDelivery) send(event Event, body []byte, attempt int) error {
+	req, err := http.NewRequest(http.MethodPost, d.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Zen-Webhook/"+buildVersion)
+	req.Header.Set("X-Zen-Event", event.Type)
+	req.Header.Set("X-Zen-Delivery", strconv.FormatUint(event.ID, 10))
+	req.Header.Set("X-Zen-Attempt", strconv.Itoa(attempt))
+	if d.config.Secret != "" {
+		req.Header.Set(eventSignatureHeader, signEventPayload(d.config.Secret, body))
+	}
+	for key, value := range d.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &webhookStatusError{status: resp.StatusCode}
+	}
+	return nil
+}
+
+func (d *webhookDelivery) Close() {
+	close(d.stop)
+	<-d.done
+}
+
+func (d *webhookDelivery) status() EventWebhookStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return EventWebhookStatus{
+		Name:          d.config.Name,
+		URL:           d.config.URL,
+		Delivered:     d.delivered,
+		Failed:        d.failed,
+		Dropped:       d.dropped,
+		Pending:       len(d.queue),
+		LastError:     d.lastError,
+		LastDelivered: d.lastDelivered,
+	}
+}
+
+type eventWebhooks struct {
+	mu         sync.RWMutex
+	configs    []EventWebhook
+	deliveries []*webhookDelivery
+}
+
+func newEventWebhooks() *eventWebhooks {
+	return &eventWebhooks{}
+}
+
+func (w *eventWebhooks) Configure(hooks []EventWebhook) {
+	w.mu.Lock()
+	if reflect.DeepEqual(w.configs, hooks) {
+		w.mu.Unlock()
+		return
+	}
+
+	existing := make(map[string]*webhookDelivery)
+	for _, delivery := range w.deliveries {
+		existing[delivery.config.Name] = delivery
+	}
+
+	invalid := make(map[string]error)
+	var deliveries []*webhookDelivery
+	for _, hook := range hooks {
+		if delivery, ok := existing[hook.Name]; ok && reflect.DeepEqual(delivery.config, hook) {
+			deliveries = append(deliveries, delivery)
+			delete(existing, hook.Name)
+			continue
+		}
+
+		delivery, err := newWebhookDelivery(hook)
+		if err != nil {
+			invalid[hook.Name] = err
+			continue
+		}
+		if previous, ok := existing[hook.Name]; ok {
+			previous.handOver(delivery)
+		}
+		go delivery.run()
+		deliveries = append(deliveries, delivery)
+	}
+
+	w.configs = hooks
+	w.deliveries = deliveries
+	w.mu.Unlock()
+
+	for _, delivery := range existing {
+		delivery.Close()
+		if dropped := len(delivery.queue); dropped > 0 {
+			webhookDeliveriesTotal.Add(float64(dropped), delivery.config.Name, "dropped")
+			subsystemLogger("events").Warn("Dropped queued events of removed event webhook", "webhook", delivery.config.Name, "events", dropped)
+		}
+	}
+	for name, err := range invalid {
+		subsystemLogger("events").Error("Invalid event webhook", "webhook", name, "error", err)
+	}
+}
+
+func (w *eventWebhooks) Deliver(event Event) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for _, delivery := range w.deliveries {
+		if delivery.accepts(event) {
+			delivery.enqueue(event)
+		}
+	}
+}
+
+func (w *eventWebhooks) Status() []EventWebhookStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	statuses := []EventWebhookStatus{}
+	for _, delivery := range w.deliveries {
+		statuses = append(statuses, delivery.status())
+	}
+	return statuses
+}
+
+func (w *eventWebhooks) Close() {
+	w.mu.Lock()
+	deliveries := w.deliveries
+	w.configs = nil
+	w.deliveries = nil
+	w.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		delivery.Close()
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	EventReleaseDetected = "release-detected"
+	EventDownloadStarted = "download-started"
+	EventInstalled       = "installed"
+	EventStarted         = "started"
+	EventHealthPassed    = "health-passed"
+	EventRolledBack      = "rolled-back"
+	EventStopped         = "stopped"
+	EventCrashed         = "crashed"
+
+	eventHistorySize      = 500
+	eventSubscriberBuffer = 64
+)
+
+var eventTypes = []string{
+	EventReleaseDetected,
+	EventDownloadStarted,
+	EventInstalled,
+	EventStarted,
+	EventHealthPassed,
+	EventRolledBack,
+	EventStopped,
+	EventCrashed,
+}
+
+type Event struct {
+	ID           uint64         `json:"id"`
+	Type         string         `json:"type"`
+	Time         time.Time      `json:"time"`
+	AppKey       string         `json:"appKey"`
+	Version      string         `json:"version,omitempty"`
+	DeploymentID string         `json:"deploymentId,omitempty"`
+	Data         map[string]any `json:"data,omitempty"`
+}
+
+type EventFilter struct {
+	Types []string
+	Apps  []string
+}
+
+func (f EventFilter) Matches(event Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+	if len(f.Apps) > 0 && !slices.Contains(f.Apps, event.AppKey) {
+		return false
+	}
+	return true
+}
+
+type eventBus struct {
+	mu          sync.Mutex
+	seq         uint64
+	history     []Event
+	subscribers map[chan Event]struct{}
+	webhooks    *eventWebhooks
+}
+
+var events = newEventBus()
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subscribers: make(map[chan Event]struct{}),
+		webhooks:    newEventWebhooks(),
+	}
+}
+
+func (b *eventBus) Publish(event Event) Event {
+	b.mu.Lock()
+	b.seq++
+	event.ID = b.seq
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.history = append(b.history, event)
+	if overflow := len(b.history) - eventHistorySize; overflow > 0 {
+		b.history = b.history[overflow:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+			subsystemLogger("events").Warn("Closing slow event subscriber", "event", event.ID)
+		}
+	}
+	b.mu.Unlock()
+
+	eventsPublishedTotal.Inc(event.Type)
+	b.webhooks.Deliver(event)
+	return event
+}
+
+func (b *eventBus) Subscribe() (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, eventSubscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *eventBus) Since(id uint64, filter EventFilter, limit int) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := []Event{}
+	for _, event := range b.history {
+		if event.ID > id && filter.Matches(event) {
+			result = append(result, event)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+func (b *eventBus) Configure(hooks []EventWebhook) {
+	b.webhooks.Configure(hooks)
+}
+
+func (b *eventBus) WebhookStatus() []EventWebhookStatus {
+	return b.webhooks.Status()
+}
+
+func (b *eventBus) Close() {
+	b.webhooks.Close()
+}
+
+func deploymentEvent(eventType string, deployment *Deployment, data map[string]any) Event {
+	return Event{
+		Type:         eventType,
+		AppKey:       deployment.AppKey,
+		Version:      deployment.Version,
+		DeploymentID: deployment.ID,
+		Data:         data,
+	}
+}
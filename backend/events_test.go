@@ -0,0 +1,221 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventBusPublishesAndReplays(t *testing.T) {
+	bus := newEventBus()
+	live, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	bus.Publish(Event{Type: EventStarted, AppKey: "org/app", Version: "1.0.0"})
+	bus.Publish(Event{Type: EventCrashed, AppKey: "org/app", Version: "1.0.0"})
+	bus.Publish(Event{Type: EventStarted, AppKey: "org/other", Version: "2.0.0"})
+
+	first := <-live
+	if first.ID != 1 || first.Type != EventStarted || first.Time.IsZero() {
+		t.Errorf("Unexpected first event %+v", first)
+	}
+
+	started := bus.Since(0, EventFilter{Types: []string{EventStarted}}, 0)
+	if len(started) != 2 || started[1].AppKey != "org/other" {
+		t.Errorf("Expected two started events, got %+v", started)
+	}
+	if replay := bus.Since(1, EventFilter{Apps: []string{"org/app"}}, 0); len(replay) != 1 || replay[0].ID != 2 {
+		t.Errorf("Expected replay after id 1, got %+v", replay)
+	}
+	if limited := bus.Since(0, EventFilter{}, 1); len(limited) != 1 || limited[0].ID != 3 {
+		t.Errorf("Expected most recent event, got %+v", limited)
+	}
+}
+
+func TestEventBusClosesSlowSubscriber(t *testing.T) {
+	bus := newEventBus()
+	live, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i <= eventSubscriberBuffer; i++ {
+		bus.Publish(Event{Type: EventStarted, AppKey: "org/app"})
+	}
+
+	received := 0
+	for range live {
+		received++
+	}
+	if received != eventSubscriberBuffer {
+		t.Errorf("Expected %d buffered events before close, got %d", eventSubscriberBuffer, received)
+	}
+	if replay := bus.Since(uint64(received), EventFilter{}, 0); len(replay) != 1 {
+		t.Errorf("Expected missed event to be replayable, got %+v", replay)
+	}
+}
+
+func TestEventWebhookSignsAndRetries(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	delivery, err := newWebhookDelivery(EventWebhook{Name: "ci", URL: server.URL, Secret: "s3cret", Events: []string{EventStarted}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	delivery.retryBase = time.Millisecond
+	go delivery.run()
+	defer delivery.Close()
+
+	event := Event{ID: 7, Type: EventStarted, AppKey: "org/app", Version: "1.1.0", Time: time.Now()}
+	if delivery.accepts(Event{Type: EventCrashed}) || !delivery.accepts(event) {
+		t.Error("Expected webhook to accept only subscribed event types")
+	}
+	delivery.enqueue(event)
+
+	select {
+	case req := <-received:
+		body := <-bodies
+		if !verifyGitHubSignature("s3cret", req.Header.Get(eventSignatureHeader), body) {
+			t.Errorf("Expected valid signature, got %q", req.Header.Get(eventSignatureHeader))
+		}
+		if req.Header.Get("X-Zen-Event") != EventStarted || req.Header.Get("X-Zen-Delivery") != "7" || req.Header.Get("X-Zen-Attempt") != "3" {
+			t.Errorf("Unexpected headers %v", req.Header)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for delivery")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for delivery.status().Delivered != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if status := delivery.status(); status.Delivered != 1 || status.Failed != 0 || status.LastError != "" {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
+
+func TestEventWebhookGivesUpOnClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	delivery, _ := newWebhookDelivery(EventWebhook{Name: "ci", URL: server.URL})
+	delivery.retryBase = time.Millisecond
+	delivery.deliver(Event{ID: 1, Type: EventStopped, AppKey: "org/app"})
+
+	if attempts.Load() != 1 {
+		t.Errorf("Expected a single attempt for 400, got %d", attempts.Load())
+	}
+	if status := delivery.status(); status.Failed != 1 || status.LastError != "webhook returned status 400" {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
+
+func TestEventWebhooksSkipInvalidConfiguration(t *testing.T) {
+	hooks := newEventWebhooks()
+	defer hooks.Close()
+
+	hooks.Configure([]EventWebhook{
+		{Name: "bad-url", URL: "ftp://example.com"},
+		{Name: "bad-event", URL: "http://example.com", Events: []string{"exploded"}},
+		{Name: "good", URL: "http://example.com", Events: []string{EventCrashed}},
+	})
+
+	statuses := hooks.Status()
+	if len(statuses) != 1 || statuses[0].Name != "good" {
+		t.Errorf("Expected only the valid webhook, got %+v", statuses)
+	}
+}
+
+func TestEventWebhooksHandOverQueuedEventsOnReconfigure(t *testing.T) {
+	release := make(chan struct{})
+	blocking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer blocking.Close()
+
+	delivered := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r.Header.Get("X-Zen-Delivery")
+	}))
+	defer server.Close()
+
+	hooks := newEventWebhooks()
+	defer hooks.Close()
+	hooks.Configure([]EventWebhook{{Name: "ci", URL: blocking.URL}})
+	for id := uint64(1); id <= 3; id++ {
+		hooks.Deliver(Event{ID: id, Type: EventStarted, AppKey: "org/app"})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for hooks.Status()[0].Pending != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	configured := make(chan struct{})
+	go func() {
+		hooks.Configure([]EventWebhook{{Name: "ci", URL: server.URL}})
+		close(configured)
+	}()
+
+	for _, want := range []string{"2", "3"} {
+		select {
+		case got := <-delivered:
+			if got != want {
+				t.Errorf("Expected event %s, got %s", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for queued event to be handed over")
+		}
+	}
+
+	close(release)
+	<-configured
+}
+
+func TestUpdateAppPublishesDeploymentEvents(t *testing.T) {
+	live, unsubscribe := events.Subscribe()
+	defer unsubscribe()
+
+	updater, _ := newHookTestUpdater(&mockHookRunner{})
+	app := App{Provider: "github", Key: "org/app", Command: "./run"}
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var received []Event
+	for len(live) > 0 {
+		if event := <-live; event.AppKey == "org/app" {
+			received = append(received, event)
+		}
+	}
+
+	if len(received) != 2 || received[0].Type != EventReleaseDetected || received[1].Type != EventStarted {
+		t.Fatalf("Expected release-detected then started, got %+v", received)
+	}
+	if received[0].Version != "1.1.0" || received[0].Data["previousVersion"] != "1.0.0" {
+		t.Errorf("Unexpected release event %+v", received[0])
+	}
+	if received[1].DeploymentID == "" {
+		t.Errorf("Expected started event tied to deployment, got %+v", received[1])
+	}
+}
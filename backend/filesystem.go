@@ -10,6 +10,8 @@ type FileSystemOps interface {
 	Create(name string) (*os.File, error)
 	Open(name string) (*os.File, error)
 	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
 	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
 }
 
@@ -43,6 +45,14 @@ func (fs *osFileSystem) Remove(name string) error {
 	return os.Remove(name)
 }
 
+func (fs *osFileSystem) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (fs *osFileSystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
 func (fs *osFileSystem) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return os.OpenFile(name, flag, perm)
 }
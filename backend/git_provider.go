@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitCacheRoot    = "/opt/zen/cache/git"
+	defaultGitBranch       = "main"
+	defaultGitBuildTimeout = 10 * time.Minute
+	gitCommandTimeout      = 5 * time.Minute
+	githubHost             = "github.com"
+)
+
+type releaseInstaller interface {
+	InstallRelease(app App, release *Release, installPath, token string) error
+}
+
+type gitProvider struct {
+	cacheRoot string
+	extractor ArchiveExtractor
+}
+
+func newGitProvider(cacheRoot string, extractor ArchiveExtractor) *gitProvider {
+	return &gitProvider{
+		cacheRoot: cacheRoot,
+		extractor: extractor,
+	}
+}
+
+func (p *gitProvider) repoDir(app App) string {
+	return filepath.Join(p.cacheRoot, toSlug(app.Key))
+}
+
+func (p *gitProvider) GetLatestRelease(app App, token string) (*Release, error) {
+	repoDir := p.repoDir(app)
+	if err := p.fetch(app, repoDir, token); err != nil {
+		return nil, err
+	}
+
+	source := app.Git
+	if source == nil {
+		source = &GitSource{}
+	}
+
+	var ref string
+	if source.TagPattern != "" {
+		tags, err := p.git(repoDir, "", "tag", "--list", source.TagPattern)
+		if err != nil {
+			return nil, err
+		}
+
+		tag, ok := latestSemverTag(strings.Fields(tags))
+		if !ok {
+			return nil, fmt.Errorf("no semver tags matching %s", source.TagPattern)
+		}
+		ref = "refs/tags/" + tag + "^{commit}"
+	} else {
+		branch := source.Branch
+		if branch == "" {
+			branch = defaultGitBranch
+		}
+		ref = "refs/remotes/origin/" + branch
+	}
+
+	sha, err := p.git(repoDir, "", "rev-parse", "--verify", ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	sha = strings.TrimSpace(sha)
+
+	return &Release{
+		Version:   sha[:12],
+		AssetName: sha + ".tar.gz",
+		AssetURL:  sha,
+	}, nil
+}
+
+func (p *gitProvider) DownloadRelease(app App, release *Release, token string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("git releases are built locally and cannot be downloaded")
+}
+
+func (p *gitProvider) InstallRelease(app App, release *Release, installPath, token string) error {
+	buildDir := filepath.Join(p.cacheRoot, fmt.Sprintf("%s-build-%s", toSlug(app.Key), release.Version))
+	os.RemoveAll(buildDir)
+	os.Remove(buildDir + ".log")
+	if err := os.MkdirAll(buildDir, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(buildDir)
+
+	archivePath := buildDir + ".tar.gz"
+	defer os.Remove(archivePath)
+
+	if _, err := p.git(p.repoDir(app), "", "archive", "--format=tar.gz", "-o", archivePath, release.AssetURL); err != nil {
+		return fmt.Errorf("failed to export commit %s: %w", release.Version, err)
+	}
+
+	if err := p.extractor.ExtractTarGz(archivePath, buildDir); err != nil {
+		return fmt.Errorf("failed to extract commit %s: %w", release.Version, err)
+	}
+
+	source := app.Git
+	if source == nil {
+		source = &GitSource{}
+	}
+
+	if source.BuildCommand != "" {
+		timeout := parseDurationOr(source.BuildTimeout, defaultGitBuildTimeout)
+		output, err := runBuild(source.BuildCommand, buildDir, release.AssetURL, timeout)
+		os.WriteFile(buildDir+".log", output, 0644)
+		if err != nil {
+			return fmt.Errorf("build failed, see %s.log: %w", buildDir, err)
+		}
+	}
+
+	outputDir := filepath.Join(buildDir, filepath.Clean("/"+source.OutputDir))
+	if _, err := os.Stat(outputDir); err != nil {
+		return fmt.Errorf("build output %s not found: %w", source.OutputDir, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(installPath), 0755); err != nil {
+		return err
+	}
+	os.RemoveAll(installPath)
+	if err := os.Rename(outputDir, installPath); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(buildDir + ".log"); err == nil {
+		os.Rename(buildDir+".log", filepath.Join(installPath, "build.log"))
+	}
+
+	return nil
+}
+
+func (p *gitProvider) fetch(app App, repoDir, token string) error {
+	if _, err := os.Stat(filepath.Join(repoDir, "HEAD")); err != nil {
+		if err := os.MkdirAll(repoDir, 0755); err != nil {
+			return err
+		}
+		if _, err := p.git(repoDir, "", "init", "--bare", "--quiet"); err != nil {
+			return fmt.Errorf("failed to init git cache: %w", err)
+		}
+		if _, err := p.git(repoDir, "", "remote", "add", "origin", app.Key); err != nil {
+			return fmt.Errorf("failed to add remote: %w", err)
+		}
+	}
+
+	_, err := p.git(repoDir, gitAuthHeader(token), "fetch", "--quiet", "--prune", "--tags", "--force", "origin",
+		"+refs/heads/*:refs/remotes/origin/*")
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", app.Key, err)
+	}
+	return nil
+}
+
+func gitAuthHeader(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	username, secret, ok := strings.Cut(token, ":")
+	if !ok {
+		username, secret = "x-access-token", token
+	}
+	return "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+secret))
+}
+
+func gitRemoteMatchesHost(remote, host string) bool {
+	u, err := url.Parse(remote)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	return strings.EqualFold(u.Host, host)
+}
+
+func (p *gitProvider) git(dir, authHeader string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if authHeader != "" {
+		cmd.Env = append(cmd.Env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0="+authHeader)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func runBuild(command, workDir, commit string, timeout time.Duration) ([]byte, error) {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + workDir,
+		"TMPDIR=" + workDir,
+		"ZEN_COMMIT=" + commit,
+	}
+	return runCommand(command, workDir, env, timeout)
+}
+
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
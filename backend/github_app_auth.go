@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const installationTokenRefreshMargin = 5 * time.Minute
+
+type InstallationTokenSource interface {
+	Token(credential Credential) (string, error)
+}
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+type githubAppTokenSource struct {
+	client     HTTPClient
+	apiBaseURL string
+	now        func() time.Time
+	mu         sync.Mutex
+	cache      map[string]installationToken
+}
+
+func newGitHubAppTokenSource(client HTTPClient, apiBaseURL string) *githubAppTokenSource {
+	return &githubAppTokenSource{
+		client:     client,
+		apiBaseURL: apiBaseURL,
+		now:        time.Now,
+		cache:      make(map[string]installationToken),
+	}
+}
+
+func (s *githubAppTokenSource) Token(credential Credential) (string, error) {
+	if credential.AppID == "" {
+		return "", fmt.Errorf("app ID is required for GitHub App credentials")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cacheKey := credential.Name + "/" + credential.AppID + "/" + credential.InstallationID
+	if cached, ok := s.cache[cacheKey]; ok && s.now().Add(installationTokenRefreshMargin).Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	appJWT, err := s.appJWT(credential)
+	if err != nil {
+		return "", err
+	}
+
+	installationID := credential.InstallationID
+	if installationID == "" {
+		installationID, err = s.findInstallation(appJWT)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/app/installations/%s/access_tokens", s.apiBaseURL, installationID), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("GitHub returned status %d creating installation token", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", err
+	}
+
+	s.cache[cacheKey] = installationToken{
+		token:     tokenResp.Token,
+		expiresAt: tokenResp.ExpiresAt,
+	}
+	return tokenResp.Token, nil
+}
+
+func (s *githubAppTokenSource) appJWT(credential Credential) (string, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(credential.Secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+
+	now := s.now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    credential.AppID,
+		IssuedAt:  jwt.NewNumericDate(now.Add(-60 * time.Second)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+}
+
+func (s *githubAppTokenSource) findInstallation(appJWT string) (string, error) {
+	req, err := http.NewRequest("GET", s.apiBaseURL+"/app/installations", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub returned status %d listing installations", resp.StatusCode)
+	}
+
+	var installations []struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&installations); err != nil {
+		return "", err
+	}
+
+	if len(installations) != 1 {
+		return "", fmt.Errorf("GitHub App has %d installations, installation ID must be set", len(installations))
+	}
+
+	return fmt.Sprintf("%d", installations[0].ID), nil
+}
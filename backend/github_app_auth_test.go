@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestGitHubAppTokenSourceMintsAndCachesTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	issued := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appJWT := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		claims := &jwt.RegisteredClaims{}
+		_, err := jwt.ParseWithClaims(appJWT, claims, func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithTimeFunc(func() time.Time { return now }))
+		if err != nil || claims.Issuer != "42" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/app/installations":
+			w.Write([]byte(`[{"id": 7}]`))
+		case "/app/installations/7/access_tokens":
+			issued++
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{
+				"token":      fmt.Sprintf("ghs_%d", issued),
+				"expires_at": now.Add(time.Hour),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := newGitHubAppTokenSource(server.Client(), server.URL)
+	source.now = func() time.Time { return now }
+	credential := Credential{Name: "app", Provider: "github-app", AppID: "42", Secret: string(privatePEM)}
+
+	token, err := source.Token(credential)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token != "ghs_1" {
+		t.Errorf("Expected token 'ghs_1', got '%s'", token)
+	}
+
+	now = now.Add(30 * time.Minute)
+	token, _ = source.Token(credential)
+	if token != "ghs_1" {
+		t.Errorf("Expected cached token 'ghs_1', got '%s'", token)
+	}
+
+	now = now.Add(26 * time.Minute)
+	token, _ = source.Token(credential)
+	if token != "ghs_2" {
+		t.Errorf("Expected refreshed token 'ghs_2', got '%s'", token)
+	}
+}
+
+func TestGitHubAppTokenSourceRejectsInvalidKey(t *testing.T) {
+	source := newGitHubAppTokenSource(&mockHTTPClient{}, githubAPIBaseURL)
+
+	if _, err := source.Token(Credential{AppID: "42", Secret: "not a key"}); err == nil {
+		t.Error("Expected invalid key error, got nil")
+	}
+}
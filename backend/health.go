@@ -1,9 +1,340 @@
 package main
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
 
-func handleHealth(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
-		"status": "ok",
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	zenRoot = "/opt/zen"
+
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthFailed   = "failed"
+	healthUnknown  = "unknown"
+
+	updaterStallAfter = 4 * schedulerTick
+	diskCriticalBytes = 256 << 20
+	diskLowRatio      = 0.10
+)
+
+type ComponentHealth struct {
+	Status  string         `json:"status"`
+	Message string         `json:"message,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type AppHealth struct {
+	AppKey         string     `json:"appKey"`
+	Status         string     `json:"status"`
+	Version        string     `json:"version,omitempty"`
+	Running        int        `json:"running"`
+	Ready          int        `json:"ready"`
+	Total          int        `json:"total"`
+	LastCheck      *time.Time `json:"lastCheck,omitempty"`
+	LastSuccess    *time.Time `json:"lastSuccess,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	PendingVersion string     `json:"pendingVersion,omitempty"`
+}
+
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Time       time.Time                  `json:"time"`
+	Components map[string]ComponentHealth `json:"components"`
+	Providers  map[string]ComponentHealth `json:"providers,omitempty"`
+	Apps       []AppHealth                `json:"apps,omitempty"`
+}
+
+func (r *HealthReport) add(name string, component ComponentHealth) {
+	r.Components[name] = component
+	r.Status = worseHealth(r.Status, component.Status)
+}
+
+func worseHealth(a, b string) string {
+	rank := map[string]int{healthOK: 0, healthUnknown: 0, healthDegraded: 1, healthFailed: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+type appCheckStatus struct {
+	lastCheck   time.Time
+	lastSuccess time.Time
+	err         string
+}
+
+type providerStatus struct {
+	lastContact time.Time
+	lastSuccess time.Time
+	err         string
+}
+
+type updaterStatus struct {
+	mu           sync.Mutex
+	heartbeat    time.Time
+	lastSchedule time.Time
+	scheduleErr  string
+	lastSuccess  time.Time
+	checks       map[string]appCheckStatus
+	providers    map[string]providerStatus
+}
+
+func (s *updaterStatus) beat(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.heartbeat = now
+}
+
+func (s *updaterStatus) scheduled(now time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSchedule = now
+	s.scheduleErr = ""
+	if err != nil {
+		s.scheduleErr = err.Error()
+	}
+}
+
+func (s *updaterStatus) providerResult(name string, now time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.providers == nil {
+		s.providers = make(map[string]providerStatus)
+	}
+	status := s.providers[name]
+	status.lastContact = now
+	status.err = ""
+	if err != nil {
+		status.err = err.Error()
+	} else {
+		status.lastSuccess = now
+	}
+	s.providers[name] = status
+}
+
+func (s *updaterStatus) checkResult(appKey string, now time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.checks == nil {
+		s.checks = make(map[string]appCheckStatus)
+	}
+	status := s.checks[appKey]
+	status.lastCheck = now
+	status.err = ""
+	if err != nil {
+		status.err = err.Error()
+	} else {
+		status.lastSuccess = now
+		s.lastSuccess = now
+	}
+	s.checks[appKey] = status
+}
+
+func (s *updaterStatus) updaterHealth(now time.Time) ComponentHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	details := map[string]any{}
+	if !s.heartbeat.IsZero() {
+		details["heartbeat"] = s.heartbeat
+	}
+	if !s.lastSchedule.IsZero() {
+		details["lastSchedule"] = s.lastSchedule
+	}
+	if !s.lastSuccess.IsZero() {
+		details["lastSuccessfulCheck"] = s.lastSuccess
+	}
+
+	switch {
+	case s.heartbeat.IsZero():
+		return ComponentHealth{Status: healthFailed, Message: "updater has not started", Details: details}
+	case now.Sub(s.heartbeat) > updaterStallAfter:
+		return ComponentHealth{Status: healthFailed, Message: fmt.Sprintf("updater stalled for %s", now.Sub(s.heartbeat).Round(time.Second)), Details: details}
+	case s.scheduleErr != "":
+		return ComponentHealth{Status: healthDegraded, Message: s.scheduleErr, Details: details}
+	}
+	return ComponentHealth{Status: healthOK, Details: details}
+}
+
+func (s *updaterStatus) providerHealth(name string) ComponentHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, ok := s.providers[name]
+	if !ok {
+		return ComponentHealth{Status: healthUnknown, Message: "not contacted yet"}
+	}
+
+	details := map[string]any{"lastContact": status.lastContact}
+	if !status.lastSuccess.IsZero() {
+		details["lastSuccess"] = status.lastSuccess
+	}
+	if status.err != "" {
+		return ComponentHealth{Status: healthDegraded, Message: status.err, Details: details}
+	}
+	return ComponentHealth{Status: healthOK, Details: details}
+}
+
+func (s *updaterStatus) appCheck(appKey string) (appCheckStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, ok := s.checks[appKey]
+	return status, ok
+}
+
+func diskSpace(path string) (total, free uint64, err error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return 0, 0, err
+	}
+	return fs.Blocks * uint64(fs.Bsize), fs.Bavail * uint64(fs.Bsize), nil
+}
+
+func checkDiskSpace(path string) ComponentHealth {
+	total, free, err := diskSpace(path)
+	if err != nil {
+		return ComponentHealth{Status: healthFailed, Message: err.Error()}
+	}
+
+	details := map[string]any{"path": path, "freeBytes": free, "totalBytes": total}
+
+	switch {
+	case free < diskCriticalBytes:
+		return ComponentHealth{Status: healthFailed, Message: "disk space critically low", Details: details}
+	case total > 0 && float64(free)/float64(total) < diskLowRatio:
+		return ComponentHealth{Status: healthDegraded, Message: "disk space low", Details: details}
+	}
+	return ComponentHealth{Status: healthOK, Details: details}
+}
+
+func (au *AppUpdater) appHealth(app App) AppHealth {
+	health := AppHealth{AppKey: app.Key, Status: healthOK}
+
+	if info, err := au.ProcessManager.GetProcess(app.Key); err == nil {
+		health.Version = info.Version
+		for _, state := range info.Processes {
+			health.Total++
+			if state.Running {
+				health.Running++
+			}
+			if state.Ready {
+				health.Ready++
+			}
+		}
+	}
+
+	if check, ok := au.status.appCheck(app.Key); ok {
+		health.LastCheck = &check.lastCheck
+		if !check.lastSuccess.IsZero() {
+			health.LastSuccess = &check.lastSuccess
+		}
+		health.Error = check.err
+	}
+	if pending, ok := au.pending.Get(app.Key); ok {
+		health.PendingVersion = pending.Version
+	}
+
+	switch {
+	case health.Total > 0 && health.Running == 0:
+		health.Status = healthFailed
+	case health.Running < health.Total || health.Error != "":
+		health.Status = healthDegraded
+	case health.Total == 0:
+		health.Status = healthUnknown
+	}
+	return health
+}
+
+func (au *AppUpdater) Liveness() HealthReport {
+	report := HealthReport{Status: healthOK, Time: time.Now(), Components: map[string]ComponentHealth{}}
+	report.add("updater", au.status.updaterHealth(report.Time))
+	return report
+}
+
+func (au *AppUpdater) Readiness() HealthReport {
+	return au.readiness(time.Now(), zenRoot)
+}
+
+func (au *AppUpdater) readiness(now time.Time, diskPath string) HealthReport {
+	report := HealthReport{Status: healthOK, Time: now, Components: map[string]ComponentHealth{}}
+	report.add("updater", au.status.updaterHealth(now))
+	report.add("disk", checkDiskSpace(diskPath))
+
+	setupData, err := au.loadSetupData()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		report.add("setup", ComponentHealth{Status: healthFailed, Message: "setup has not been completed"})
+		return report
+	case err != nil:
+		report.add("setup", ComponentHealth{Status: healthFailed, Message: fmt.Sprintf("failed to load setup data: %v", err)})
+		return report
+	}
+	report.add("setup", ComponentHealth{Status: healthOK, Details: map[string]any{"apps": len(setupData.Apps)}})
+
+	report.Providers = map[string]ComponentHealth{}
+	degradedApps := 0
+	for _, app := range setupData.Apps {
+		if _, ok := report.Providers[app.Provider]; !ok {
+			provider := au.status.providerHealth(app.Provider)
+			report.Providers[app.Provider] = provider
+			report.Status = worseHealth(report.Status, provider.Status)
+		}
+
+		health := au.appHealth(app)
+		if worseHealth(healthOK, health.Status) != healthOK {
+			degradedApps++
+		}
+		report.Apps = append(report.Apps, health)
+	}
+	sort.Slice(report.Apps, func(i, j int) bool {
+		return report.Apps[i].AppKey < report.Apps[j].AppKey
 	})
+
+	apps := ComponentHealth{Status: healthOK, Details: map[string]any{"total": len(report.Apps), "unhealthy": degradedApps}}
+	if degradedApps > 0 {
+		apps.Status = healthDegraded
+		apps.Message = fmt.Sprintf("%d of %d apps unhealthy", degradedApps, len(report.Apps))
+	}
+	report.add("apps", apps)
+	return report
+}
+
+func (r HealthReport) summary() HealthReport {
+	summary := HealthReport{Status: r.Status, Time: r.Time, Components: make(map[string]ComponentHealth, len(r.Components))}
+	for name, component := range r.Components {
+		summary.Components[name] = ComponentHealth{Status: component.Status}
+	}
+	return summary
+}
+
+func healthStatusCode(report HealthReport) int {
+	if report.Status == healthFailed {
+		return fiber.StatusServiceUnavailable
+	}
+	return fiber.StatusOK
+}
+
+func handleHealth(c *fiber.Ctx) error {
+	report := appUpdater.Liveness()
+	return c.Status(healthStatusCode(report)).JSON(report)
+}
+
+func handleReady(c *fiber.Ctx) error {
+	report := appUpdater.Readiness()
+	if !isAuthenticated(c) {
+		return c.Status(healthStatusCode(report)).JSON(report.summary())
+	}
+	return c.Status(healthStatusCode(report)).JSON(report)
 }
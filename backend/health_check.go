@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	defaultHealthCheckTimeout  = 60 * time.Second
+	defaultHealthCheckInterval = 2 * time.Second
+)
+
+type HealthChecker interface {
+	Check(check HealthCheck, workDir string, env []string) error
+}
+
+type pollingHealthChecker struct {
+	client HTTPClient
+}
+
+func (h *pollingHealthChecker) Check(check HealthCheck, workDir string, env []string) error {
+	if check.HTTP == "" && check.TCP == "" && check.Command == "" {
+		return nil
+	}
+
+	timeout := parseDurationOr(check.Timeout, defaultHealthCheckTimeout)
+	interval := parseDurationOr(check.Interval, defaultHealthCheckInterval)
+	deadline := time.Now().Add(timeout)
+
+	var lastErr error
+	for {
+		lastErr = h.probe(check, workDir, env, interval)
+		if lastErr == nil {
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("health check did not pass within %s: %w", timeout, lastErr)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (h *pollingHealthChecker) probe(check HealthCheck, workDir string, env []string, timeout time.Duration) error {
+	if check.Command != "" {
+		if _, err := runCommand(check.Command, workDir, append(os.Environ(), env...), timeout); err != nil {
+			return err
+		}
+	}
+
+	if check.TCP != "" {
+		conn, err := net.DialTimeout("tcp", check.TCP, timeout)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+	}
+
+	if check.HTTP != "" {
+		req, err := http.NewRequest("GET", check.HTTP, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := h.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("%s returned status %d", check.HTTP, resp.StatusCode)
+		}
+	}
+
+	return nil
+}
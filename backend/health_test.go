@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newHealthTestUpdater(t *testing.T) (*AppUpdater, *mockFileSystemUpdater, *mockProcessManager) {
+	t.Helper()
+
+	fs := newMockFileSystem()
+	pm := newMockProcessManager()
+	au := NewAppUpdater("/data/setup.json", fs, nil, nil, pm, nil)
+	return au, fs, pm
+}
+
+func TestLivenessFailsWhenUpdaterStalls(t *testing.T) {
+	au, _, _ := newHealthTestUpdater(t)
+
+	if report := au.Liveness(); report.Status != healthFailed {
+		t.Errorf("Expected failed before updater starts, got %s", report.Status)
+	}
+
+	au.status.beat(time.Now())
+	if report := au.Liveness(); report.Status != healthOK {
+		t.Errorf("Expected ok after heartbeat, got %s", report.Status)
+	}
+
+	au.status.beat(time.Now().Add(-2 * updaterStallAfter))
+	report := au.Liveness()
+	if report.Status != healthFailed || report.Components["updater"].Message == "" {
+		t.Errorf("Expected stalled updater to fail liveness, got %+v", report)
+	}
+}
+
+func TestReadinessReportsSetupAndApps(t *testing.T) {
+	au, fs, pm := newHealthTestUpdater(t)
+	now := time.Now()
+	au.status.beat(now)
+	disk := t.TempDir()
+
+	report := au.readiness(now, disk)
+	if report.Status != healthFailed || report.Components["setup"].Message != "setup has not been completed" {
+		t.Errorf("Expected missing setup to fail readiness, got %+v", report.Components["setup"])
+	}
+
+	fs.files["/data/setup.json"] = []byte("{not json")
+	if report := au.readiness(now, disk); report.Status != healthFailed {
+		t.Errorf("Expected corrupt setup to fail readiness, got %s", report.Status)
+	}
+
+	setup, _ := json.Marshal(SetupData{Apps: []App{
+		{Key: "org/web", Provider: "github"},
+		{Key: "org/api", Provider: "github"},
+	}})
+	fs.files["/data/setup.json"] = setup
+	pm.processes["org/web"] = &ProcessInfo{AppKey: "org/web", Version: "v1", Processes: []ProcessState{
+		{Type: "web", Running: true, Ready: true},
+	}}
+	pm.processes["org/api"] = &ProcessInfo{AppKey: "org/api", Version: "v2", Processes: []ProcessState{
+		{Type: "web", Running: false},
+	}}
+	au.status.providerResult("github", now, errors.New("connection refused"))
+	au.status.checkResult("org/api", now, errors.New("failed to get latest release"))
+	au.status.checkResult("org/web", now, nil)
+
+	report = au.readiness(now, disk)
+	if report.Components["setup"].Status != healthOK {
+		t.Errorf("Expected setup ok, got %+v", report.Components["setup"])
+	}
+	if report.Providers["github"].Status != healthDegraded {
+		t.Errorf("Expected unreachable provider to be degraded, got %+v", report.Providers["github"])
+	}
+	if len(report.Apps) != 2 || report.Apps[0].AppKey != "org/api" || report.Apps[0].Status != healthFailed || report.Apps[1].Status != healthOK {
+		t.Errorf("Unexpected app summary %+v", report.Apps)
+	}
+	if report.Apps[1].LastSuccess == nil || report.Apps[0].Error == "" {
+		t.Errorf("Expected check results in app summary, got %+v", report.Apps)
+	}
+	if report.Status == healthFailed || report.Components["apps"].Status != healthDegraded {
+		t.Errorf("Expected unhealthy apps to degrade but not fail readiness, got %s %+v", report.Status, report.Components["apps"])
+	}
+}
+
+func TestHealthEndpointsReturnServiceUnavailable(t *testing.T) {
+	au, _, _ := newHealthTestUpdater(t)
+	original := appUpdater
+	appUpdater = au
+	defer func() { appUpdater = original }()
+
+	app := fiber.New()
+	app.Get("/api/health", handleHealth)
+	app.Get("/api/ready", handleReady)
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/api/health", nil))
+	if resp.StatusCode != 503 {
+		t.Errorf("Expected 503 for stalled updater, got %d", resp.StatusCode)
+	}
+
+	au.status.beat(time.Now())
+	resp, _ = app.Test(httptest.NewRequest("GET", "/api/health", nil))
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected 200 for running updater, got %d", resp.StatusCode)
+	}
+
+	resp, _ = app.Test(httptest.NewRequest("GET", "/api/ready", nil))
+	if resp.StatusCode != 503 {
+		t.Errorf("Expected 503 without setup, got %d", resp.StatusCode)
+	}
+}
+
+func TestReadyHidesDetailsWithoutAuth(t *testing.T) {
+	au, fs, _ := newHealthTestUpdater(t)
+	original, originalSecret := appUpdater, jwtSecret
+	appUpdater, jwtSecret = au, []byte("test-secret")
+	defer func() { appUpdater, jwtSecret = original, originalSecret }()
+
+	setup, _ := json.Marshal(SetupData{Apps: []App{{Key: "org/private-app", Provider: "github"}}})
+	fs.files["/data/setup.json"] = setup
+	au.status.beat(time.Now())
+	au.status.checkResult("org/private-app", time.Now(), errors.New("token rejected for org/private-app"))
+
+	app := fiber.New()
+	app.Get("/api/ready", handleReady)
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/api/ready", nil))
+	body, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(body), "private-app") || strings.Contains(string(body), "providers") {
+		t.Errorf("Expected no app details without auth, got %s", body)
+	}
+	var summary HealthReport
+	json.Unmarshal(body, &summary)
+	if summary.Status == "" || summary.Components["apps"].Status != healthDegraded || summary.Components["updater"].Status != healthOK {
+		t.Errorf("Expected component statuses without auth, got %s", body)
+	}
+
+	token, _ := generateJWT("admin")
+	req := httptest.NewRequest("GET", "/api/ready", nil)
+	req.AddCookie(&http.Cookie{Name: "auth_token", Value: token})
+	resp, _ = app.Test(req)
+	body, _ = io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "token rejected for org/private-app") {
+		t.Errorf("Expected app details with auth, got %s", body)
+	}
+}
+
+func TestReadinessTreatsNotStartedAppsAsUnknown(t *testing.T) {
+	au, fs, _ := newHealthTestUpdater(t)
+	now := time.Now()
+	au.status.beat(now)
+
+	setup, _ := json.Marshal(SetupData{Apps: []App{{Key: "org/new", Provider: "github"}}})
+	fs.files["/data/setup.json"] = setup
+	au.status.providerResult("github", now, nil)
+
+	report := au.readiness(now, t.TempDir())
+	if len(report.Apps) != 1 || report.Apps[0].Status != healthUnknown {
+		t.Errorf("Expected app without processes to be unknown, got %+v", report.Apps)
+	}
+	if report.Components["apps"].Status != healthOK {
+		t.Errorf("Expected apps not yet started to keep readiness ok, got %+v", report.Components["apps"])
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	if health := checkDiskSpace(t.TempDir()); health.Details["totalBytes"] == nil {
+		t.Errorf("Expected disk details, got %+v", health)
+	}
+	if health := checkDiskSpace("/does/not/exist"); health.Status != healthFailed {
+		t.Errorf("Expected failure for missing path, got %s", health.Status)
+	}
+}
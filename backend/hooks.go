@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+)
+
+const defaultHookTimeout = 5 * time.Minute
+
+type HookRunner interface {
+	Run(command, workDir string, env []string, timeout time.Duration) ([]byte, error)
+}
+
+type shellHookRunner struct{}
+
+func (r *shellHookRunner) Run(command, workDir string, env []string, timeout time.Duration) ([]byte, error) {
+	return runCommand(command, workDir, append(os.Environ(), env...), timeout)
+}
+
+func appEnv(app App, version, previousVersion, installPath string) []string {
+	keys := make([]string, 0, len(app.Env))
+	for key := range app.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys)+4)
+	for _, key := range keys {
+		env = append(env, key+"="+app.Env[key])
+	}
+
+	return append(env,
+		"ZEN_APP_KEY="+app.Key,
+		"ZEN_VERSION="+version,
+		"ZEN_PREVIOUS_VERSION="+previousVersion,
+		"ZEN_INSTALL_PATH="+installPath,
+	)
+}
+
+func (au *AppUpdater) runHook(app App, deployment *Deployment, name, command, workDir string, env []string) error {
+	if command == "" {
+		return nil
+	}
+
+	timeout := defaultHookTimeout
+	if app.Hooks != nil {
+		timeout = parseDurationOr(app.Hooks.Timeout, defaultHookTimeout)
+	}
+
+	deploymentLogger(deployment).Info("Running hook", "hook", name)
+
+	started := au.now()
+	output, err := au.hooks.Run(command, workDir, env, timeout)
+
+	result := HookResult{
+		Name:       name,
+		Command:    command,
+		Output:     string(output),
+		StartedAt:  started,
+		DurationMs: au.now().Sub(started).Milliseconds(),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		result.ExitCode = exitCode(err)
+	}
+
+	deployment.Hooks = append(deployment.Hooks, result)
+
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
+
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
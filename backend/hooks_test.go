@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type hookCall struct {
+	command string
+	workDir string
+	env     []string
+}
+
+type mockHookRunner struct {
+	calls    []hookCall
+	failures map[string]error
+}
+
+func (m *mockHookRunner) Run(command, workDir string, env []string, timeout time.Duration) ([]byte, error) {
+	m.calls = append(m.calls, hookCall{command, workDir, env})
+	return []byte("output of " + command), m.failures[command]
+}
+
+func newHookTestUpdater(hooks *mockHookRunner) (*AppUpdater, *mockProcessManager) {
+	fs := newMockFileSystem()
+	fs.directories["/opt/zen/apps/org-app-1.1.0"] = true
+
+	processManager := newMockProcessManager()
+	processManager.Start("org/app", "1.0.0", "/opt/zen/apps/org-app-1.0.0", []ProcessSpec{{Type: "web", Command: "./run"}}, nil)
+
+	updater := NewAppUpdater("/opt/zen/data/setup.json", fs, &mockArchiveExtractor{}, newTestReleaseDownloader("v1.1.0"), processManager, nil)
+	updater.hooks = hooks
+	return updater, processManager
+}
+
+func TestDeployRunsHooksInOrder(t *testing.T) {
+	hooks := &mockHookRunner{}
+	updater, processManager := newHookTestUpdater(hooks)
+
+	app := App{
+		Provider: "github",
+		Key:      "org/app",
+		Command:  "./run",
+		Env:      map[string]string{"DATABASE_URL": "postgres://db"},
+		Hooks:    &Hooks{PreStart: "migrate", PreStop: "drain", PostStart: "warmup", OnFailure: "alert"},
+	}
+
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(hooks.calls) != 3 {
+		t.Fatalf("Expected 3 hook calls, got %+v", hooks.calls)
+	}
+
+	expected := []hookCall{
+		{command: "migrate", workDir: "/opt/zen/apps/org-app-1.1.0"},
+		{command: "drain", workDir: "/opt/zen/apps/org-app-1.0.0"},
+		{command: "warmup", workDir: "/opt/zen/apps/org-app-1.1.0"},
+	}
+	for i, call := range hooks.calls {
+		if call.command != expected[i].command || call.workDir != expected[i].workDir {
+			t.Errorf("Hook %d = %s in %s, expected %s in %s", i, call.command, call.workDir, expected[i].command, expected[i].workDir)
+		}
+		version := "ZEN_VERSION=1.1.0"
+		if call.command == "drain" {
+			version = "ZEN_VERSION=1.0.0"
+		}
+		if !slices.Contains(call.env, "DATABASE_URL=postgres://db") || !slices.Contains(call.env, version) || !slices.Contains(call.env, "ZEN_INSTALL_PATH="+call.workDir) {
+			t.Errorf("Hook %s missing app environment: %v", call.command, call.env)
+		}
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process.Version != "1.1.0" {
+		t.Errorf("Expected 1.1.0 running, got %s", process.Version)
+	}
+
+	deployments, _ := updater.Deployments("org/app")
+	if len(deployments) != 1 || deployments[0].Status != DeploymentSucceeded || len(deployments[0].Hooks) != 3 {
+		t.Fatalf("Expected one successful deployment with 3 hooks, got %+v", deployments)
+	}
+	if deployments[0].Hooks[0].Output != "output of migrate" {
+		t.Errorf("Expected captured hook output, got '%s'", deployments[0].Hooks[0].Output)
+	}
+}
+
+func TestPreStopHookComesFromOutgoingRelease(t *testing.T) {
+	hooks := &mockHookRunner{}
+	updater, _ := newHookTestUpdater(hooks)
+	fs := updater.fs.(*mockFileSystemUpdater)
+	fs.files["/opt/zen/apps/org-app-1.0.0/zen.yaml"] = []byte("hooks:\n  preStop: ./old-drain\n")
+	fs.files["/opt/zen/apps/org-app-1.1.0/zen.yaml"] = []byte("hooks:\n  preStop: ./new-drain\n")
+
+	app := App{Provider: "github", Key: "org/app", Command: "./run"}
+	if err := updater.updateApp(app, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(hooks.calls) != 1 || hooks.calls[0].command != "./old-drain" || hooks.calls[0].workDir != "/opt/zen/apps/org-app-1.0.0" {
+		t.Errorf("Expected outgoing release's pre-stop hook, got %+v", hooks.calls)
+	}
+}
+
+func TestFailingPreStartHookKeepsOldVersion(t *testing.T) {
+	hooks := &mockHookRunner{failures: map[string]error{"migrate": errors.New("exit status 1")}}
+	updater, processManager := newHookTestUpdater(hooks)
+
+	app := App{
+		Provider: "github",
+		Key:      "org/app",
+		Command:  "./run",
+		Hooks:    &Hooks{PreStart: "migrate", PostStart: "warmup", OnFailure: "alert"},
+	}
+
+	if err := updater.updateApp(app, "", false); err == nil {
+		t.Fatal("Expected pre-start hook error, got nil")
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process.Version != "1.0.0" {
+		t.Errorf("Expected 1.0.0 to keep running, got %s", process.Version)
+	}
+
+	if len(processManager.stopped) != 0 {
+		t.Errorf("Expected old version not to be stopped, got %v", processManager.stopped)
+	}
+
+	if len(hooks.calls) != 2 || hooks.calls[1].command != "alert" {
+		t.Errorf("Expected pre-start followed by on-failure hook, got %+v", hooks.calls)
+	}
+
+	deployments, _ := updater.Deployments("org/app")
+	if len(deployments) != 1 || deployments[0].Status != DeploymentAborted {
+		t.Fatalf("Expected one aborted deployment, got %+v", deployments)
+	}
+}
+
+func TestShellHookRunnerCapturesExitCode(t *testing.T) {
+	updater := NewAppUpdater("/opt/zen/data/setup.json", newMockFileSystem(), &mockArchiveExtractor{}, &mockGitHubDownloader{}, newMockProcessManager(), nil)
+	deployment := &Deployment{Version: "1.0.0"}
+	app := App{Key: "org/app", Hooks: &Hooks{Timeout: "5s"}}
+
+	err := updater.runHook(app, deployment, "pre-start", "echo $GREETING; exit 3", t.TempDir(), []string{"GREETING=hello"})
+	if err == nil {
+		t.Fatal("Expected hook error, got nil")
+	}
+
+	result := deployment.Hooks[0]
+	if result.ExitCode != 3 || strings.TrimSpace(result.Output) != "hello" {
+		t.Errorf("Expected exit code 3 with output 'hello', got %d '%s'", result.ExitCode, result.Output)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	OverlapSkip  = "skip"
+	OverlapQueue = "queue"
+	OverlapAllow = "allow"
+
+	JobRunning   = "running"
+	JobQueued    = "queued"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobSkipped   = "skipped"
+
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+
+	defaultJobTimeout = time.Hour
+	jobHistoryLimit   = 100
+)
+
+var (
+	ErrJobNotFound   = errors.New("job not found")
+	ErrAppNotRunning = errors.New("app is not running")
+)
+
+type JobRun struct {
+	ID         string    `json:"id"`
+	AppKey     string    `json:"appKey"`
+	Job        string    `json:"job"`
+	Version    string    `json:"version"`
+	Trigger    string    `json:"trigger"`
+	Status     string    `json:"status"`
+	ExitCode   int       `json:"exitCode"`
+	Output     string    `json:"output,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+}
+
+type jobScheduler struct {
+	mu           sync.Mutex
+	nextRuns     map[string]time.Time
+	badSchedules map[string]string
+	running      map[string]int
+	queued       map[string]bool
+}
+
+func newJobScheduler() *jobScheduler {
+	return &jobScheduler{
+		nextRuns:     make(map[string]time.Time),
+		badSchedules: make(map[string]string),
+		running:      make(map[string]int),
+		queued:       make(map[string]bool),
+	}
+}
+
+func (s *jobScheduler) begin(key, overlap string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running[key] > 0 {
+		switch overlap {
+		case OverlapAllow:
+		case OverlapQueue:
+			s.queued[key] = true
+			return JobQueued
+		default:
+			return JobSkipped
+		}
+	}
+
+	s.running[key]++
+	return JobRunning
+}
+
+func (s *jobScheduler) finish(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running[key]--
+	if s.running[key] > 0 {
+		return false
+	}
+	delete(s.running, key)
+
+	if s.queued[key] {
+		delete(s.queued, key)
+		s.running[key]++
+		return true
+	}
+	return false
+}
+
+func jobKey(appKey, jobName string) string {
+	return appKey + "/" + jobName
+}
+
+func (au *AppUpdater) scheduleDueJobs(now time.Time, setupData *SetupData) {
+	au.jobs.mu.Lock()
+	defer au.jobs.mu.Unlock()
+
+	configured := make(map[string]bool)
+	for _, app := range setupData.Apps {
+		app, installPath, version, err := au.currentRelease(app)
+		if err != nil {
+			continue
+		}
+
+		for _, job := range app.Jobs {
+			key := jobKey(app.Key, job.Name)
+			configured[key] = true
+
+			if job.Schedule == "" {
+				delete(au.jobs.badSchedules, key)
+				continue
+			}
+			schedule, err := parseSchedule(job.Schedule)
+			if err != nil {
+				if au.jobs.badSchedules[key] != job.Schedule {
+					subsystemLogger("jobs").Warn("Invalid job schedule", "op", "schedule", "app", app.Key, "job", job.Name, "error", err)
+					au.jobs.badSchedules[key] = job.Schedule
+				}
+				continue
+			}
+			delete(au.jobs.badSchedules, key)
+			if schedule == nil {
+				continue
+			}
+
+			next, scheduled := au.jobs.nextRuns[key]
+			au.jobs.nextRuns[key] = schedule.Next(now)
+			if scheduled && !now.Before(next) {
+				go au.startJob(app, job, version, installPath, JobTriggerSchedule)
+			} else if scheduled {
+				au.jobs.nextRuns[key] = next
+			}
+		}
+	}
+
+	for key := range au.jobs.nextRuns {
+		if !configured[key] {
+			delete(au.jobs.nextRuns, key)
+		}
+	}
+	for key := range au.jobs.badSchedules {
+		if !configured[key] {
+			delete(au.jobs.badSchedules, key)
+		}
+	}
+}
+
+func (au *AppUpdater) currentRelease(app App) (App, string, string, error) {
+	process, err := au.ProcessManager.GetProcess(app.Key)
+	if err != nil {
+		return app, "", "", ErrAppNotRunning
+	}
+
+	merged, err := au.appForRelease(app, process.InstallPath)
+	if err != nil {
+		subsystemLogger("jobs").Warn("Ignoring manifest", "app", app.Key, "version", process.Version, "error", err)
+	}
+	return merged, process.InstallPath, process.Version, nil
+}
+
+func (au *AppUpdater) RunJob(appKey, jobName string) (JobRun, error) {
+	app, err := au.findAppByKey(appKey)
+	if err != nil {
+		return JobRun{}, err
+	}
+
+	merged, installPath, version, err := au.currentRelease(*app)
+	if err != nil {
+		return JobRun{}, err
+	}
+
+	for _, job := range merged.Jobs {
+		if job.Name == jobName {
+			return au.startJob(merged, job, version, installPath, JobTriggerManual), nil
+		}
+	}
+	return JobRun{}, ErrJobNotFound
+}
+
+func (au *AppUpdater) startJob(app App, job Job, version, installPath, trigger string) JobRun {
+	run := JobRun{
+		ID:        newDeploymentID(),
+		AppKey:    app.Key,
+		Job:       job.Name,
+		Version:   version,
+		Trigger:   trigger,
+		StartedAt: au.now(),
+	}
+
+	key := jobKey(app.Key, job.Name)
+	run.Status = au.jobs.begin(key, job.Overlap)
+	switch run.Status {
+	case JobSkipped:
+		jobLogger(run).Info("Job still running, skipping")
+		run.FinishedAt = run.StartedAt
+		au.recordJobRun(run)
+		return run
+	case JobQueued:
+		jobLogger(run).Info("Job still running, queueing")
+		return run
+	}
+
+	go func() {
+		au.executeJob(app, job, run, installPath)
+		for au.jobs.finish(key) {
+			current, currentPath, currentVersion, err := au.currentRelease(app)
+			if err != nil {
+				subsystemLogger("jobs").Warn("Dropping queued run", "op", "run", "app", app.Key, "job", job.Name, "error", err)
+				continue
+			}
+			queued := run
+			queued.ID = newDeploymentID()
+			queued.Version = currentVersion
+			queued.StartedAt = au.now()
+			au.executeJob(current, job, queued, currentPath)
+		}
+	}()
+	return run
+}
+
+func (au *AppUpdater) executeJob(app App, job Job, run JobRun, installPath string) {
+	jobLogger(run).Info("Running job")
+
+	env := append(appEnv(app, run.Version, "", installPath), "ZEN_JOB_NAME="+job.Name)
+	output, err := au.hooks.Run(job.Command, installPath, env, parseDurationOr(job.Timeout, defaultJobTimeout))
+
+	run.Output = string(output)
+	run.FinishedAt = au.now()
+	run.Status = JobSucceeded
+	if err != nil {
+		run.Status = JobFailed
+		run.Error = err.Error()
+		run.ExitCode = exitCode(err)
+		jobLogger(run).Error("Job failed", "exitCode", run.ExitCode, "error", err)
+	}
+
+	au.recordJobRun(run)
+}
+
+func (au *AppUpdater) recordJobRun(run JobRun) {
+	if err := au.jobHistory.Record(run); err != nil {
+		jobLogger(run).Error("Failed to record job run", "error", err)
+	}
+}
+
+func jobLogger(run JobRun) *slog.Logger {
+	return subsystemLogger("jobs").With("op", "run", "app", run.AppKey, "job", run.Job, "version", run.Version, "runId", run.ID)
+}
+
+func (au *AppUpdater) JobRuns(appKey string) ([]JobRun, error) {
+	return au.jobHistory.List(appKey)
+}
+
+type jobHistory struct {
+	fs       FileSystemOps
+	filePath string
+	mu       sync.Mutex
+}
+
+func newJobHistory(fs FileSystemOps, filePath string) *jobHistory {
+	return &jobHistory{
+		fs:       fs,
+		filePath: filePath,
+	}
+}
+
+func (h *jobHistory) Record(run JobRun) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history, err := h.load()
+	if err != nil {
+		return err
+	}
+
+	runs := append([]JobRun{run}, history[run.AppKey]...)
+	if len(runs) > jobHistoryLimit {
+		runs = runs[:jobHistoryLimit]
+	}
+	history[run.AppKey] = runs
+
+	if err := h.fs.MkdirAll(filepath.Dir(h.filePath), 0755); err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return h.fs.WriteFile(h.filePath, jsonData, 0600)
+}
+
+func (h *jobHistory) List(appKey string) ([]JobRun, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := history[appKey]
+	if runs == nil {
+		runs = []JobRun{}
+	}
+	return runs, nil
+}
+
+func (h *jobHistory) load() (map[string][]JobRun, error) {
+	data, err := h.fs.ReadFile(h.filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(map[string][]JobRun), nil
+		}
+		return nil, err
+	}
+
+	history := make(map[string][]JobRun)
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("invalid job history: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockJobRunner struct {
+	mu      sync.Mutex
+	calls   []hookCall
+	release chan struct{}
+	err     error
+}
+
+func (m *mockJobRunner) Run(command, workDir string, env []string, timeout time.Duration) ([]byte, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, hookCall{command, workDir, env})
+	m.mu.Unlock()
+
+	if m.release != nil {
+		<-m.release
+	}
+	return []byte("cleaned up"), m.err
+}
+
+func newJobTestUpdater(runner HookRunner, jobs []Job) *AppUpdater {
+	updater, _ := newHookTestUpdater(nil)
+	updater.hooks = runner
+
+	setupData := SetupData{Apps: []App{{Provider: "github", Key: "org/app", Command: "./run", Jobs: jobs}}}
+	data, _ := json.Marshal(setupData)
+	updater.fs.(*mockFileSystemUpdater).files["/opt/zen/data/setup.json"] = data
+	return updater
+}
+
+func waitForJobRuns(t *testing.T, updater *AppUpdater, count int) []JobRun {
+	t.Helper()
+
+	var runs []JobRun
+	waitFor(t, 2*time.Second, func() bool {
+		runs, _ = updater.JobRuns("org/app")
+		return len(runs) >= count
+	})
+	return runs
+}
+
+func TestRunJobExecutesInCurrentRelease(t *testing.T) {
+	runner := &mockJobRunner{}
+	updater := newJobTestUpdater(runner, []Job{{Name: "cleanup", Command: "./cleanup --old"}})
+
+	run, err := updater.RunJob("org/app", "cleanup")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if run.Status != JobRunning || run.Version != "1.0.0" || run.Trigger != JobTriggerManual {
+		t.Errorf("Unexpected run %+v", run)
+	}
+
+	runs := waitForJobRuns(t, updater, 1)
+	if runs[0].Status != JobSucceeded || runs[0].Output != "cleaned up" {
+		t.Errorf("Expected succeeded run with output, got %+v", runs[0])
+	}
+
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	if runner.calls[0].workDir != "/opt/zen/apps/org-app-1.0.0" {
+		t.Errorf("Expected job to run in current release, got %s", runner.calls[0].workDir)
+	}
+	if !slices.Contains(runner.calls[0].env, "ZEN_JOB_NAME=cleanup") {
+		t.Errorf("Expected job environment, got %v", runner.calls[0].env)
+	}
+}
+
+func TestRunJobRecordsFailure(t *testing.T) {
+	runner := &mockJobRunner{err: errors.New("exit status 3")}
+	updater := newJobTestUpdater(runner, []Job{{Name: "cleanup", Command: "./cleanup"}})
+
+	if _, err := updater.RunJob("org/app", "cleanup"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	runs := waitForJobRuns(t, updater, 1)
+	if runs[0].Status != JobFailed || runs[0].ExitCode != -1 || runs[0].Error == "" {
+		t.Errorf("Expected failed run, got %+v", runs[0])
+	}
+}
+
+func TestRunJobErrors(t *testing.T) {
+	updater := newJobTestUpdater(&mockJobRunner{}, []Job{{Name: "cleanup", Command: "./cleanup"}})
+
+	if _, err := updater.RunJob("org/app", "missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+
+	updater.ProcessManager.Stop("org/app")
+	if _, err := updater.RunJob("org/app", "cleanup"); !errors.Is(err, ErrAppNotRunning) {
+		t.Errorf("Expected ErrAppNotRunning, got %v", err)
+	}
+}
+
+func TestRunJobOverlapPolicies(t *testing.T) {
+	tests := []struct {
+		overlap string
+		status  string
+		runs    int
+	}{
+		{OverlapSkip, JobSkipped, 2},
+		{OverlapQueue, JobQueued, 2},
+		{OverlapAllow, JobRunning, 2},
+	}
+
+	for _, tt := range tests {
+		runner := &mockJobRunner{release: make(chan struct{})}
+		updater := newJobTestUpdater(runner, []Job{{Name: "cleanup", Command: "./cleanup", Overlap: tt.overlap}})
+
+		updater.RunJob("org/app", "cleanup")
+		second, err := updater.RunJob("org/app", "cleanup")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if second.Status != tt.status {
+			t.Errorf("%s: expected second run %s, got %s", tt.overlap, tt.status, second.Status)
+		}
+
+		close(runner.release)
+		runs := waitForJobRuns(t, updater, tt.runs)
+		if len(runs) != tt.runs {
+			t.Errorf("%s: expected %d recorded runs, got %d", tt.overlap, tt.runs, len(runs))
+		}
+	}
+}
+
+func TestScheduleDueJobs(t *testing.T) {
+	runner := &mockJobRunner{}
+	updater := newJobTestUpdater(runner, []Job{
+		{Name: "nightly", Schedule: "0 3 * * *", Command: "./cleanup"},
+		{Name: "manual", Command: "./reindex"},
+	})
+
+	start := time.Date(2024, 6, 1, 2, 59, 30, 0, time.UTC)
+	setup, _ := updater.loadSetupData()
+	updater.scheduleDueJobs(start, setup)
+	updater.scheduleDueJobs(start.Add(15*time.Second), setup)
+
+	if runs, _ := updater.JobRuns("org/app"); len(runs) != 0 {
+		t.Fatalf("Expected no runs before 03:00, got %+v", runs)
+	}
+
+	updater.scheduleDueJobs(start.Add(30*time.Second), setup)
+	runs := waitForJobRuns(t, updater, 1)
+	if runs[0].Job != "nightly" || runs[0].Trigger != JobTriggerSchedule {
+		t.Errorf("Expected scheduled nightly run, got %+v", runs[0])
+	}
+}
+
+func TestScheduleDueJobsRemembersInvalidSchedules(t *testing.T) {
+	updater := newJobTestUpdater(&mockJobRunner{}, nil)
+	now := time.Date(2024, 6, 1, 2, 59, 30, 0, time.UTC)
+	key := jobKey("org/app", "nightly")
+
+	setup := &SetupData{Apps: []App{{Provider: "github", Key: "org/app", Command: "./run", Jobs: []Job{
+		{Name: "nightly", Schedule: "not a schedule", Command: "./cleanup"},
+	}}}}
+	updater.scheduleDueJobs(now, setup)
+	if updater.jobs.badSchedules[key] != "not a schedule" {
+		t.Errorf("Expected invalid schedule to be remembered, got %v", updater.jobs.badSchedules)
+	}
+
+	setup.Apps[0].Jobs[0].Schedule = "0 3 * * *"
+	updater.scheduleDueJobs(now, setup)
+	if _, ok := updater.jobs.badSchedules[key]; ok {
+		t.Error("Expected fixed schedule to be forgotten")
+	}
+
+	setup.Apps[0].Jobs[0].Schedule = "still wrong"
+	updater.scheduleDueJobs(now, setup)
+	setup.Apps[0].Jobs = nil
+	updater.scheduleDueJobs(now, setup)
+	if len(updater.jobs.badSchedules) != 0 {
+		t.Errorf("Expected removed job to be forgotten, got %v", updater.jobs.badSchedules)
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	BalanceRoundRobin       = "round-robin"
+	BalanceLeastConnections = "least-connections"
+
+	backendCheckInterval   = 5 * time.Second
+	backendCheckTimeout    = 2 * time.Second
+	backendFailureCooldown = 10 * time.Second
+)
+
+type backendSource func() []ProcessState
+
+type loadBalancer struct {
+	appKey      string
+	processType string
+	port        int
+	source      backendSource
+	client      HTTPClient
+	server      *http.Server
+	mu          sync.Mutex
+	balance     string
+	healthPath  string
+	next        int
+	active      map[int]int
+	unhealthy   map[int]time.Time
+	proxies     map[int]*httputil.ReverseProxy
+	stop        chan struct{}
+}
+
+func newLoadBalancer(appKey string, spec ProcessSpec, source backendSource) *loadBalancer {
+	lb := &loadBalancer{
+		appKey:      appKey,
+		processType: spec.Type,
+		port:        spec.Port,
+		source:      source,
+		client:      &http.Client{Timeout: backendCheckTimeout},
+		balance:     spec.Balance,
+		healthPath:  spec.HealthPath,
+		active:      make(map[int]int),
+		unhealthy:   make(map[int]time.Time),
+		proxies:     make(map[int]*httputil.ReverseProxy),
+		stop:        make(chan struct{}),
+	}
+	lb.server = &http.Server{Handler: lb}
+	return lb
+}
+
+func (lb *loadBalancer) logger() *slog.Logger {
+	return subsystemLogger("proxy").With("app", lb.appKey, "type", lb.processType, "port", lb.port)
+}
+
+func (lb *loadBalancer) Listen() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", lb.port))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := lb.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			lb.logger().Error("Proxy stopped", "error", err)
+		}
+	}()
+	go lb.checkBackends()
+	return nil
+}
+
+func (lb *loadBalancer) Close() {
+	close(lb.stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	lb.server.Shutdown(ctx)
+}
+
+func (lb *loadBalancer) update(spec ProcessSpec) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.balance = spec.Balance
+	lb.healthPath = spec.HealthPath
+}
+
+func (lb *loadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	port, proxy, ok := lb.acquire()
+	if !ok {
+		http.Error(w, "no healthy replicas available", http.StatusServiceUnavailable)
+		return
+	}
+	defer lb.release(port)
+
+	proxy.ServeHTTP(w, r)
+}
+
+func (lb *loadBalancer) newProxy(port int) *httputil.ReverseProxy {
+	target := &url.URL{Scheme: "http", Host: "127.0.0.1:" + strconv.Itoa(port)}
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, context.Canceled) || r.Context().Err() != nil {
+				lb.logger().Debug("Client went away", "replicaPort", port, "error", err)
+				return
+			}
+			lb.logger().Warn("Replica request failed", "replicaPort", port, "error", err)
+			lb.markUnhealthy(port, backendFailureCooldown)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+func (lb *loadBalancer) backends() []int {
+	now := time.Now()
+
+	var ports []int
+	for _, state := range lb.source() {
+		if state.Type != lb.processType || !state.Running || !state.Ready || state.Port == 0 {
+			continue
+		}
+		if until, ok := lb.unhealthy[state.Port]; ok && now.Before(until) {
+			continue
+		}
+		ports = append(ports, state.Port)
+	}
+	return ports
+}
+
+func (lb *loadBalancer) acquire() (int, *httputil.ReverseProxy, bool) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	ports := lb.backends()
+	if len(ports) == 0 {
+		return 0, nil, false
+	}
+
+	lb.next++
+	port := ports[lb.next%len(ports)]
+	if lb.balance == BalanceLeastConnections {
+		for i := range ports {
+			candidate := ports[(lb.next+i)%len(ports)]
+			if lb.active[candidate] < lb.active[port] {
+				port = candidate
+			}
+		}
+	}
+
+	lb.active[port]++
+	proxy, ok := lb.proxies[port]
+	if !ok {
+		proxy = lb.newProxy(port)
+		lb.proxies[port] = proxy
+	}
+	return port, proxy, true
+}
+
+func (lb *loadBalancer) release(port int) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.active[port]--
+	if lb.active[port] <= 0 {
+		delete(lb.active, port)
+	}
+}
+
+func (lb *loadBalancer) markUnhealthy(port int, cooldown time.Duration) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.unhealthy[port] = time.Now().Add(cooldown)
+}
+
+func (lb *loadBalancer) markHealthy(port int) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	delete(lb.unhealthy, port)
+}
+
+func (lb *loadBalancer) checkBackends() {
+	ticker := time.NewTicker(backendCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lb.stop:
+			return
+		case <-ticker.C:
+		}
+
+		lb.mu.Lock()
+		healthPath := lb.healthPath
+		lb.mu.Unlock()
+
+		states := lb.source()
+		lb.prune(states)
+		for _, state := range states {
+			if state.Type != lb.processType || !state.Running || !state.Ready || state.Port == 0 {
+				continue
+			}
+
+			err := lb.probe(state.Port, healthPath)
+			recordHealthCheck(lb.appKey, "proxy", err)
+			if err != nil {
+				lb.logger().Warn("Removing unhealthy replica", "replica", state.Replica, "error", err)
+				lb.markUnhealthy(state.Port, 2*backendCheckInterval)
+			} else {
+				lb.markHealthy(state.Port)
+			}
+		}
+	}
+}
+
+func (lb *loadBalancer) prune(states []ProcessState) {
+	current := make(map[int]bool)
+	for _, state := range states {
+		if state.Type == lb.processType && state.Port != 0 {
+			current[state.Port] = true
+		}
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	for port := range lb.proxies {
+		if !current[port] {
+			delete(lb.proxies, port)
+		}
+	}
+	for port := range lb.unhealthy {
+		if !current[port] {
+			delete(lb.unhealthy, port)
+		}
+	}
+}
+
+func (lb *loadBalancer) probe(port int, healthPath string) error {
+	address := "127.0.0.1:" + strconv.Itoa(port)
+	if healthPath == "" {
+		conn, err := net.DialTimeout("tcp", address, backendCheckTimeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequest("GET", "http://"+address+healthPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := lb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type proxyManager struct {
+	processes ProcessManager
+	mu        sync.Mutex
+	balancers map[int]*loadBalancer
+}
+
+func newProxyManager(processes ProcessManager) *proxyManager {
+	return &proxyManager{
+		processes: processes,
+		balancers: make(map[int]*loadBalancer),
+	}
+}
+
+func (m *proxyManager) Ensure(appKey string, specs []ProcessSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ports := make(map[int]bool)
+	for _, spec := range specs {
+		if spec.Port == 0 {
+			continue
+		}
+		ports[spec.Port] = true
+
+		if lb, ok := m.balancers[spec.Port]; ok {
+			if lb.appKey != appKey || lb.processType != spec.Type {
+				return fmt.Errorf("port %d is already used by %s %s", spec.Port, lb.appKey, lb.processType)
+			}
+			lb.update(spec)
+			continue
+		}
+
+		lb := newLoadBalancer(appKey, spec, m.source(appKey))
+		if err := lb.Listen(); err != nil {
+			return fmt.Errorf("failed to listen on port %d: %w", spec.Port, err)
+		}
+		m.balancers[spec.Port] = lb
+		subsystemLogger("proxy").Info("Proxying port to replicas", "app", appKey, "type", spec.Type, "port", spec.Port)
+	}
+
+	for port, lb := range m.balancers {
+		if lb.appKey == appKey && !ports[port] {
+			lb.Close()
+			delete(m.balancers, port)
+		}
+	}
+	return nil
+}
+
+func (m *proxyManager) CloseAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for port, lb := range m.balancers {
+		lb.Close()
+		delete(m.balancers, port)
+	}
+}
+
+func (m *proxyManager) source(appKey string) backendSource {
+	return func() []ProcessState {
+		info, err := m.processes.GetProcess(appKey)
+		if err != nil {
+			return nil
+		}
+		return info.Processes
+	}
+}
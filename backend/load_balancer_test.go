@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func newTestBackend(t *testing.T, name string) (*httptest.Server, int) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name))
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return server, port
+}
+
+func TestLoadBalancerRoundRobin(t *testing.T) {
+	_, portA := newTestBackend(t, "a")
+	_, portB := newTestBackend(t, "b")
+
+	lb := newLoadBalancer("org/app", ProcessSpec{Type: "web", Port: 8080, Balance: BalanceRoundRobin}, func() []ProcessState {
+		return []ProcessState{
+			{Type: "web", Replica: 0, Port: portA, Running: true, Ready: true},
+			{Type: "web", Replica: 1, Port: portB, Running: true, Ready: true},
+			{Type: "worker", Replica: 0, Running: true, Ready: true},
+		}
+	})
+
+	seen := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		seen[rec.Body.String()]++
+	}
+
+	if seen["a"] != 2 || seen["b"] != 2 {
+		t.Errorf("Expected requests spread evenly, got %v", seen)
+	}
+}
+
+func TestLoadBalancerSkipsUnreadyAndUnhealthyReplicas(t *testing.T) {
+	_, portA := newTestBackend(t, "a")
+	_, portB := newTestBackend(t, "b")
+	_, portC := newTestBackend(t, "c")
+
+	lb := newLoadBalancer("org/app", ProcessSpec{Type: "web", Port: 8080}, func() []ProcessState {
+		return []ProcessState{
+			{Type: "web", Replica: 0, Port: portA, Running: true, Ready: true},
+			{Type: "web", Replica: 1, Port: portB, Running: true, Ready: false},
+			{Type: "web", Replica: 2, Port: portC, Running: true, Ready: true},
+		}
+	})
+	lb.markUnhealthy(portC, backendFailureCooldown)
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Body.String() != "a" {
+			t.Errorf("Expected only replica a to serve, got '%s'", rec.Body.String())
+		}
+	}
+}
+
+func TestLoadBalancerLeastConnections(t *testing.T) {
+	lb := newLoadBalancer("org/app", ProcessSpec{Type: "web", Port: 8080, Balance: BalanceLeastConnections}, func() []ProcessState {
+		return []ProcessState{
+			{Type: "web", Replica: 0, Port: 9001, Running: true, Ready: true},
+			{Type: "web", Replica: 1, Port: 9002, Running: true, Ready: true},
+		}
+	})
+
+	first, _, _ := lb.acquire()
+	second, _, _ := lb.acquire()
+	if first == second {
+		t.Fatalf("Expected different replicas, got %d twice", first)
+	}
+
+	lb.release(first)
+	if next, _, _ := lb.acquire(); next != first {
+		t.Errorf("Expected idle replica %d, got %d", first, next)
+	}
+}
+
+func TestLoadBalancerUnavailableWithoutReplicas(t *testing.T) {
+	lb := newLoadBalancer("org/app", ProcessSpec{Type: "web", Port: 8080}, func() []ProcessState {
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", rec.Code)
+	}
+}
+
+func TestLoadBalancerMarksFailingReplicaUnhealthy(t *testing.T) {
+	server, port := newTestBackend(t, "a")
+	server.Close()
+
+	lb := newLoadBalancer("org/app", ProcessSpec{Type: "web", Port: 8080}, func() []ProcessState {
+		return []ProcessState{{Type: "web", Port: port, Running: true, Ready: true}}
+	})
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 after failure, got %d", rec.Code)
+	}
+}
+
+func TestLoadBalancerIgnoresClientCancellation(t *testing.T) {
+	_, port := newTestBackend(t, "a")
+
+	lb := newLoadBalancer("org/app", ProcessSpec{Type: "web", Port: 8080}, func() []ProcessState {
+		return []ProcessState{{Type: "web", Port: port, Running: true, Ready: true}}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != "a" {
+		t.Errorf("Expected replica to stay in rotation after client cancellation, got %d '%s'", rec.Code, rec.Body.String())
+	}
+	if len(lb.proxies) != 1 {
+		t.Errorf("Expected one proxy reused across requests, got %d", len(lb.proxies))
+	}
+}
+
+func TestLoadBalancerPrunesReplacedReplicas(t *testing.T) {
+	_, portA := newTestBackend(t, "a")
+	_, portB := newTestBackend(t, "b")
+
+	states := []ProcessState{{Type: "web", Replica: 0, Port: portA, Running: true, Ready: true}}
+	lb := newLoadBalancer("org/app", ProcessSpec{Type: "web", Port: 8080}, func() []ProcessState {
+		return states
+	})
+
+	port, _, _ := lb.acquire()
+	lb.release(port)
+	lb.markUnhealthy(portA, backendFailureCooldown)
+
+	states = []ProcessState{{Type: "web", Replica: 0, Port: portB, Running: true, Ready: true}}
+	port, _, _ = lb.acquire()
+	lb.release(port)
+	lb.prune(states)
+
+	if _, ok := lb.proxies[portA]; ok || len(lb.proxies) != 1 {
+		t.Errorf("Expected only the current replica's proxy, got %v", lb.proxies)
+	}
+	if _, ok := lb.unhealthy[portA]; ok {
+		t.Errorf("Expected replaced replica's health state to be dropped, got %v", lb.unhealthy)
+	}
+}
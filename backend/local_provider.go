@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultInboxRoot = "/opt/zen/inbox"
+
+type archiveDigest struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+type localProvider struct {
+	inboxRoot  string
+	settleTime time.Duration
+	mu         sync.Mutex
+	digests    map[string]archiveDigest
+}
+
+func newLocalProvider(inboxRoot string) *localProvider {
+	return &localProvider{
+		inboxRoot:  inboxRoot,
+		settleTime: 10 * time.Second,
+		digests:    make(map[string]archiveDigest),
+	}
+}
+
+func (p *localProvider) inboxDir(app App) string {
+	return filepath.Join(p.inboxRoot, toSlug(app.Key))
+}
+
+func (p *localProvider) GetLatestRelease(app App, token string) (*Release, error) {
+	dir := p.inboxDir(app)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest os.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !isSupportedArchive(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) < p.settleTime {
+			continue
+		}
+
+		if latest == nil || info.ModTime().After(latest.ModTime()) ||
+			(info.ModTime().Equal(latest.ModTime()) && info.Name() > latest.Name()) {
+			latest = info
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("no archives found in %s", dir)
+	}
+
+	path := filepath.Join(dir, latest.Name())
+	digest, err := p.digest(path, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Release{
+		Version:   trimArchiveExt(latest.Name()) + "-" + digest[:12],
+		AssetName: latest.Name(),
+		AssetURL:  path,
+	}, nil
+}
+
+func (p *localProvider) digest(path string, info os.FileInfo) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cached, ok := p.digests[path]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sum, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	for cachedPath := range p.digests {
+		if filepath.Dir(cachedPath) == filepath.Dir(path) {
+			delete(p.digests, cachedPath)
+		}
+	}
+	p.digests[path] = archiveDigest{size: info.Size(), modTime: info.ModTime(), sum: sum}
+	return sum, nil
+}
+
+func (p *localProvider) DownloadRelease(app App, release *Release, token string) (io.ReadCloser, error) {
+	file, err := os.Open(release.AssetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, err := os.ReadFile(release.AssetURL + ".sha256")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file, nil
+		}
+		file.Close()
+		return nil, err
+	}
+
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 {
+		file.Close()
+		return nil, fmt.Errorf("empty checksum file for %s", release.AssetName)
+	}
+
+	return &digestVerifyingReader{
+		body:     file,
+		hash:     sha256.New(),
+		expected: strings.ToLower(fields[0]),
+	}, nil
+}
+
+func trimArchiveExt(name string) string {
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
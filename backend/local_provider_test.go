@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeInboxFile(t *testing.T, dir, name, content string, modTime time.Time) {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set mtime on %s: %v", name, err)
+	}
+}
+
+func TestLocalProviderPicksNewestSettledArchive(t *testing.T) {
+	root := t.TempDir()
+	provider := newLocalProvider(root)
+	app := App{Provider: "local", Key: "My/App"}
+
+	dir := filepath.Join(root, "my-app")
+	os.MkdirAll(dir, 0755)
+
+	now := time.Now()
+	writeInboxFile(t, dir, "app-1.0.0.tar.gz", "old", now.Add(-time.Hour))
+	writeInboxFile(t, dir, "app-1.1.0.zip", "new", now.Add(-time.Minute))
+	writeInboxFile(t, dir, "app-1.2.0.tar.gz", "partial", now)
+	writeInboxFile(t, dir, "notes.txt", "ignored", now.Add(-time.Second*30))
+
+	release, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("new"))
+	if expected := "app-1.1.0-" + hex.EncodeToString(sum[:])[:12]; release.Version != expected {
+		t.Errorf("Expected version '%s', got '%s'", expected, release.Version)
+	}
+
+	if release.AssetName != "app-1.1.0.zip" {
+		t.Errorf("Expected asset 'app-1.1.0.zip', got '%s'", release.AssetName)
+	}
+}
+
+func TestLocalProviderDetectsRedroppedArchive(t *testing.T) {
+	root := t.TempDir()
+	provider := newLocalProvider(root)
+	provider.settleTime = 0
+	app := App{Provider: "local", Key: "app"}
+
+	dir := filepath.Join(root, "app")
+	os.MkdirAll(dir, 0755)
+
+	writeInboxFile(t, dir, "app-1.0.0.tar.gz", "first build", time.Now().Add(-time.Hour))
+	first, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	writeInboxFile(t, dir, "app-1.0.0.tar.gz", "first build", time.Now().Add(-time.Minute))
+	if touched, _ := provider.GetLatestRelease(app, ""); touched.Version != first.Version {
+		t.Errorf("Expected unchanged content to keep version %s, got %s", first.Version, touched.Version)
+	}
+
+	writeInboxFile(t, dir, "app-1.0.0.tar.gz", "second build", time.Now().Add(-time.Second))
+	second, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if second.Version == first.Version {
+		t.Errorf("Expected re-dropped archive with new content to get a new version, got %s", second.Version)
+	}
+}
+
+func TestLocalProviderVerifiesChecksum(t *testing.T) {
+	root := t.TempDir()
+	provider := newLocalProvider(root)
+	provider.settleTime = 0
+	app := App{Provider: "local", Key: "app"}
+
+	dir := filepath.Join(root, "app")
+	os.MkdirAll(dir, 0755)
+
+	past := time.Now().Add(-time.Minute)
+	sum := sha256.Sum256([]byte("expected"))
+	writeInboxFile(t, dir, "app-2.tar.gz", "tampered", past)
+	writeInboxFile(t, dir, "app-2.tar.gz.sha256", hex.EncodeToString(sum[:])+"  app-2.tar.gz\n", past)
+
+	release, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	body, err := provider.DownloadRelease(app, release, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer body.Close()
+
+	if _, err := io.ReadAll(body); err == nil {
+		t.Error("Expected checksum mismatch error, got nil")
+	}
+}
+
+func TestLocalProviderEmptyInbox(t *testing.T) {
+	provider := newLocalProvider(t.TempDir())
+
+	if _, err := provider.GetLatestRelease(App{Provider: "local", Key: "app"}, ""); err == nil {
+		t.Error("Expected error for empty inbox, got nil")
+	}
+}
+
+func TestChecksumMismatchIsNeverDeployed(t *testing.T) {
+	inbox := t.TempDir()
+	provider := newLocalProvider(inbox)
+	provider.settleTime = 0
+
+	dir := filepath.Join(inbox, "app")
+	os.MkdirAll(dir, 0755)
+	past := time.Now().Add(-time.Minute)
+	sum := sha256.Sum256([]byte("expected"))
+	writeInboxFile(t, dir, "app-2.tar.gz", "tampered", past)
+	writeInboxFile(t, dir, "app-2.tar.gz.sha256", hex.EncodeToString(sum[:])+"  app-2.tar.gz\n", past)
+
+	processManager := newMockProcessManager()
+	updater := NewAppUpdater(filepath.Join(t.TempDir(), "setup.json"), &osFileSystem{}, &mockArchiveExtractor{}, &mockGitHubDownloader{}, processManager, nil)
+	updater.appsRoot = t.TempDir()
+	updater.RegisterProvider("local", provider)
+
+	app := App{Provider: "local", Key: "app", Command: "./run"}
+	for poll := 1; poll <= 2; poll++ {
+		if err := updater.updateApp(app, "", false); err == nil {
+			t.Errorf("Expected checksum error on poll %d, got nil", poll)
+		}
+	}
+
+	if len(processManager.started) != 0 {
+		t.Errorf("Expected nothing to be deployed, got %+v", processManager.started)
+	}
+	if entries, _ := os.ReadDir(updater.appsRoot); len(entries) != 0 {
+		t.Errorf("Expected no install directories left behind, got %d", len(entries))
+	}
+}
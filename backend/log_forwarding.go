@@ -0,0 +1,408 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LogSinkSyslog   = "syslog"
+	LogSinkJournald = "journald"
+	LogSinkLoki     = "loki"
+
+	LogSourceApps = "apps"
+	LogSourceZen  = "zen"
+
+	defaultSinkBatchSize     = 100
+	defaultSinkFlushInterval = 2 * time.Second
+	defaultSinkBufferSize    = 10000
+	maxSinkRetryDelay        = time.Minute
+	sinkInputBuffer          = 1024
+)
+
+type LogSink struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	Address       string            `json:"address,omitempty"`
+	Sources       []string          `json:"sources,omitempty"`
+	Apps          []string          `json:"apps,omitempty"`
+	MinLevel      string            `json:"minLevel,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	BatchSize     int               `json:"batchSize,omitempty"`
+	FlushInterval string            `json:"flushInterval,omitempty"`
+	BufferSize    int               `json:"bufferSize,omitempty"`
+}
+
+type ForwardedLog struct {
+	Time        time.Time
+	Source      string
+	AppKey      string
+	Version     string
+	ProcessType string
+	Replica     int
+	Stream      string
+	Level       string
+	Message     string
+}
+
+func forwardedAppLine(line LogLine) ForwardedLog {
+	return ForwardedLog{
+		Time:        line.Time,
+		Source:      LogSourceApps,
+		AppKey:      line.AppKey,
+		Version:     line.Version,
+		ProcessType: line.Type,
+		Replica:     line.Replica,
+		Stream:      line.Stream,
+		Level:       detectLogLevel(line.Message),
+		Message:     line.Message,
+	}
+}
+
+func forwardedSystemEntry(entry SystemLogEntry) ForwardedLog {
+	record := ForwardedLog{
+		Time:    entry.Time,
+		Source:  LogSourceZen,
+		Level:   strings.ToLower(entry.Level),
+		Message: entry.Message,
+	}
+	if record.Level == "warning" {
+		record.Level = LogLevelWarn
+	}
+	if app, ok := entry.Attrs["app"].(string); ok {
+		record.AppKey = app
+	}
+	if version, ok := entry.Attrs["version"].(string); ok {
+		record.Version = version
+	}
+
+	var attrs []string
+	for key, value := range entry.Attrs {
+		attrs = append(attrs, fmt.Sprintf("%s=%v", key, value))
+	}
+	slices.Sort(attrs)
+	if len(attrs) > 0 {
+		record.Message += " " + strings.Join(attrs, " ")
+	}
+	return record
+}
+
+type LogSinkWriter interface {
+	Send(batch []ForwardedLog) error
+	Close() error
+}
+
+func newLogSinkWriter(sink LogSink) (LogSinkWriter, error) {
+	switch sink.Type {
+	case LogSinkSyslog:
+		return newSyslogSink(sink)
+	case LogSinkJournald:
+		return newJournaldSink(sink), nil
+	case LogSinkLoki:
+		return newLokiSink(sink)
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q", sink.Type)
+	}
+}
+
+type LogSinkStatus struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Sent      int64     `json:"sent"`
+	Dropped   int64     `json:"dropped"`
+	Pending   int       `json:"pending"`
+	LastError string    `json:"lastError,omitempty"`
+	LastSent  time.Time `json:"lastSent,omitempty"`
+}
+
+type logForwarder struct {
+	config        LogSink
+	writer        LogSinkWriter
+	batchSize     int
+	bufferSize    int
+	flushInterval time.Duration
+	input         chan ForwardedLog
+	stop          chan struct{}
+	done          chan struct{}
+
+	mu        sync.Mutex
+	pending   []ForwardedLog
+	retryAt   time.Time
+	backoff   time.Duration
+	sent      int64
+	dropped   int64
+	lastError string
+	lastSent  time.Time
+}
+
+func newLogForwarder(config LogSink, writer LogSinkWriter) *logForwarder {
+	return &logForwarder{
+		config:        config,
+		writer:        writer,
+		batchSize:     positiveOr(config.BatchSize, defaultSinkBatchSize),
+		bufferSize:    positiveOr(config.BufferSize, defaultSinkBufferSize),
+		flushInterval: parseDurationOr(config.FlushInterval, defaultSinkFlushInterval),
+		input:         make(chan ForwardedLog, sinkInputBuffer),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+func (f *logForwarder) accepts(record ForwardedLog) bool {
+	sources := f.config.Sources
+	if len(sources) == 0 {
+		sources = []string{LogSourceApps, LogSourceZen}
+	}
+	if !slices.Contains(sources, record.Source) {
+		return false
+	}
+	if len(f.config.Apps) > 0 && !slices.Contains(f.config.Apps, record.AppKey) {
+		return false
+	}
+	if f.config.MinLevel != "" && logLevelRanks[record.Level] < logLevelRanks[f.config.MinLevel] {
+		return false
+	}
+	return true
+}
+
+func (f *logForwarder) Forward(record ForwardedLog) {
+	select {
+	case f.input <- record:
+	default:
+		f.mu.Lock()
+		f.dropped++
+		f.mu.Unlock()
+	}
+}
+
+func (f *logForwarder) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record := <-f.input:
+			f.enqueue(record)
+			if f.pendingCount() >= f.batchSize {
+				f.flush(time.Now())
+			}
+		case now := <-ticker.C:
+			f.flush(now)
+		case <-f.stop:
+		drain:
+			for {
+				select {
+				case record := <-f.input:
+					f.enqueue(record)
+				default:
+					break drain
+				}
+			}
+			f.flush(time.Time{})
+			f.writer.Close()
+			return
+		}
+	}
+}
+
+func (f *logForwarder) enqueue(record ForwardedLog) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pending = append(f.pending, record)
+	if overflow := len(f.pending) - f.bufferSize; overflow > 0 {
+		f.pending = f.pending[overflow:]
+		f.dropped += int64(overflow)
+	}
+}
+
+func (f *logForwarder) pendingCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending)
+}
+
+func (f *logForwarder) flush(now time.Time) {
+	for {
+		f.mu.Lock()
+		if len(f.pending) == 0 || (!now.IsZero() && now.Before(f.retryAt)) {
+			f.mu.Unlock()
+			return
+		}
+		batch := slices.Clone(f.pending[:min(len(f.pending), f.batchSize)])
+		f.mu.Unlock()
+
+		err := f.writer.Send(batch)
+
+		f.mu.Lock()
+		wasFailing := f.lastError != ""
+		if err != nil {
+			f.backoff = min(max(f.backoff*2, time.Second), maxSinkRetryDelay)
+			f.retryAt = time.Now().Add(f.backoff)
+			f.lastError = err.Error()
+			f.mu.Unlock()
+
+			if !wasFailing {
+				f.logger().Warn("Log sink failing, buffering records", "error", err)
+			}
+			return
+		}
+
+		f.pending = f.pending[min(len(batch), len(f.pending)):]
+		f.sent += int64(len(batch))
+		f.lastSent = time.Now()
+		f.lastError = ""
+		f.backoff = 0
+		f.retryAt = time.Time{}
+		f.mu.Unlock()
+
+		if wasFailing {
+			f.logger().Info("Log sink recovered")
+		}
+	}
+}
+
+func (f *logForwarder) logger() *slog.Logger {
+	return subsystemLogger("forwarding").With("sink", f.config.Name, "type", f.config.Type)
+}
+
+func (f *logForwarder) Close() {
+	close(f.stop)
+	<-f.done
+}
+
+func (f *logForwarder) status() LogSinkStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return LogSinkStatus{
+		Name:      f.config.Name,
+		Type:      f.config.Type,
+		Sent:      f.sent,
+		Dropped:   f.dropped,
+		Pending:   len(f.pending) + len(f.input),
+		LastError: f.lastError,
+		LastSent:  f.lastSent,
+	}
+}
+
+type logForwarding struct {
+	mu         sync.RWMutex
+	configs    []LogSink
+	forwarders []*logForwarder
+}
+
+var logSinks = newLogForwarding()
+
+func newLogForwarding() *logForwarding {
+	return &logForwarding{}
+}
+
+func (lf *logForwarding) Configure(sinks []LogSink) {
+	lf.mu.Lock()
+	if reflect.DeepEqual(lf.configs, sinks) {
+		lf.mu.Unlock()
+		return
+	}
+
+	var started []*logForwarder
+	invalid := make(map[string]error)
+
+	existing := make(map[string]*logForwarder)
+	for _, forwarder := range lf.forwarders {
+		existing[forwarder.config.Name] = forwarder
+	}
+
+	var forwarders []*logForwarder
+	for _, sink := range sinks {
+		if forwarder, ok := existing[sink.Name]; ok && reflect.DeepEqual(forwarder.config, sink) {
+			forwarders = append(forwarders, forwarder)
+			delete(existing, sink.Name)
+			continue
+		}
+
+		writer, err := newLogSinkWriter(sink)
+		if err != nil {
+			invalid[sink.Name] = err
+			continue
+		}
+		forwarder := newLogForwarder(sink, writer)
+		go forwarder.run()
+		forwarders = append(forwarders, forwarder)
+		started = append(started, forwarder)
+	}
+
+	lf.configs = sinks
+	lf.forwarders = forwarders
+	lf.mu.Unlock()
+
+	for _, forwarder := range existing {
+		forwarder.Close()
+	}
+	for name, err := range invalid {
+		subsystemLogger("forwarding").Error("Invalid log sink", "sink", name, "error", err)
+	}
+	for _, forwarder := range started {
+		forwarder.logger().Info("Log sink configured")
+	}
+}
+
+func (lf *logForwarding) Forward(record ForwardedLog) {
+	lf.mu.RLock()
+	defer lf.mu.RUnlock()
+
+	for _, forwarder := range lf.forwarders {
+		if forwarder.accepts(record) {
+			forwarder.Forward(record)
+		}
+	}
+}
+
+func (lf *logForwarding) ForwardLine(line LogLine) {
+	if lf.active() {
+		lf.Forward(forwardedAppLine(line))
+	}
+}
+
+func (lf *logForwarding) ForwardEntry(entry SystemLogEntry) {
+	if lf.active() {
+		lf.Forward(forwardedSystemEntry(entry))
+	}
+}
+
+func (lf *logForwarding) active() bool {
+	lf.mu.RLock()
+	defer lf.mu.RUnlock()
+	return len(lf.forwarders) > 0
+}
+
+func (lf *logForwarding) Status() []LogSinkStatus {
+	lf.mu.RLock()
+	defer lf.mu.RUnlock()
+
+	statuses := []LogSinkStatus{}
+	for _, forwarder := range lf.forwarders {
+		statuses = append(statuses, forwarder.status())
+	}
+	return statuses
+}
+
+func (lf *logForwarding) Close() {
+	lf.mu.Lock()
+	forwarders := lf.forwarders
+	lf.forwarders = nil
+	lf.configs = nil
+	lf.mu.Unlock()
+
+	for _, forwarder := range forwarders {
+		forwarder.Close()
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockSinkWriter struct {
+	mu       sync.Mutex
+	failures int
+	batches  [][]ForwardedLog
+}
+
+func (m *mockSinkWriter) Send(batch []ForwardedLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("connection refused")
+	}
+	m.batches = append(m.batches, batch)
+	return nil
+}
+
+func (m *mockSinkWriter) Close() error {
+	return nil
+}
+
+func (m *mockSinkWriter) messages() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var messages []string
+	for _, batch := range m.batches {
+		for _, record := range batch {
+			messages = append(messages, record.Message)
+		}
+	}
+	return messages
+}
+
+var testRecord = ForwardedLog{
+	Time:        time.Date(2024, 6, 1, 3, 12, 0, 500000000, time.UTC),
+	Source:      LogSourceApps,
+	AppKey:      "org/app",
+	Version:     "1.2.0",
+	ProcessType: "web",
+	Replica:     1,
+	Stream:      StreamStderr,
+	Level:       LogLevelError,
+	Message:     "ERROR db timeout",
+}
+
+func TestLogForwarderBatchesAndRetries(t *testing.T) {
+	writer := &mockSinkWriter{failures: 1}
+	forwarder := newLogForwarder(LogSink{Name: "test", BatchSize: 2, BufferSize: 3}, writer)
+
+	for _, message := range []string{"a", "b", "c", "d"} {
+		forwarder.enqueue(ForwardedLog{Message: message})
+	}
+
+	forwarder.flush(time.Now())
+	status := forwarder.status()
+	if status.Pending != 3 || status.Dropped != 1 || status.LastError == "" {
+		t.Fatalf("Expected buffered records after failure, got %+v", status)
+	}
+
+	forwarder.flush(time.Now())
+	if len(writer.messages()) != 0 {
+		t.Error("Expected no retry before backoff elapsed")
+	}
+
+	forwarder.flush(time.Now().Add(2 * time.Second))
+	if got := strings.Join(writer.messages(), ""); got != "bcd" {
+		t.Errorf("Expected 'bcd' delivered, got '%s'", got)
+	}
+	if len(writer.batches) != 2 || len(writer.batches[0]) != 2 {
+		t.Errorf("Expected batches of 2, got %v", writer.batches)
+	}
+	if status := forwarder.status(); status.Pending != 0 || status.Sent != 3 || status.LastError != "" {
+		t.Errorf("Expected recovered status, got %+v", status)
+	}
+}
+
+func TestLogForwarderAccepts(t *testing.T) {
+	zen := ForwardedLog{Source: LogSourceZen, Level: LogLevelInfo}
+	tests := []struct {
+		sink     LogSink
+		record   ForwardedLog
+		expected bool
+	}{
+		{LogSink{}, testRecord, true},
+		{LogSink{}, zen, true},
+		{LogSink{Sources: []string{LogSourceApps}}, zen, false},
+		{LogSink{Apps: []string{"org/other"}}, testRecord, false},
+		{LogSink{MinLevel: LogLevelWarn}, zen, false},
+		{LogSink{MinLevel: LogLevelWarn}, testRecord, true},
+	}
+
+	for _, tt := range tests {
+		if got := newLogForwarder(tt.sink, &mockSinkWriter{}).accepts(tt.record); got != tt.expected {
+			t.Errorf("%+v: expected %v, got %v", tt.sink, tt.expected, got)
+		}
+	}
+}
+
+func TestLogForwardingConfigureAndForward(t *testing.T) {
+	forwarding := newLogForwarding()
+	defer forwarding.Close()
+
+	forwarding.Configure([]LogSink{{Name: "bad", Type: "kafka"}})
+	if len(forwarding.Status()) != 0 {
+		t.Error("Expected invalid sink to be skipped")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	sinks := []LogSink{{Name: "loki", Type: LogSinkLoki, Address: server.URL, FlushInterval: "10ms"}}
+	forwarding.Configure(sinks)
+	first := forwarding.forwarders[0]
+	forwarding.Configure([]LogSink{{Name: "loki", Type: LogSinkLoki, Address: server.URL, FlushInterval: "10ms"}})
+	if forwarding.forwarders[0] != first {
+		t.Error("Expected unchanged sink to keep its forwarder")
+	}
+
+	forwarding.ForwardLine(LogLine{Time: time.Now(), AppKey: "org/app", Type: "web", Message: "hello"})
+	waitFor(t, 2*time.Second, func() bool {
+		return forwarding.Status()[0].Sent == 1
+	})
+}
+
+func TestSyslogSinkFormatsRFC5424(t *testing.T) {
+	sink, err := newSyslogSink(LogSink{Address: "tcp://127.0.0.1:601", Labels: map[string]string{"env": `prod "eu"`}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sink.hostname = "host1"
+
+	expected := `<11>1 2024-06-01T03:12:00.500000Z host1 org-app web.1 stderr [zen@32473 source="apps" app="org/app" version="1.2.0" type="web" replica="1" stream="stderr" env="prod \"eu\""] ERROR db timeout`
+	if got := sink.format(testRecord); got != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, got)
+	}
+
+	if _, err := newSyslogSink(LogSink{Address: "http://localhost"}); err == nil {
+		t.Error("Expected error for unsupported transport")
+	}
+}
+
+func TestSyslogSinkSendsOverTCPWithOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		length, _ := reader.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		message := make([]byte, n)
+		io.ReadFull(reader, message)
+		received <- string(message)
+	}()
+
+	sink, _ := newSyslogSink(LogSink{Address: "tcp://" + listener.Addr().String()})
+	defer sink.Close()
+	if err := sink.Send([]ForwardedLog{testRecord}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	select {
+	case message := <-received:
+		if !strings.HasPrefix(message, "<11>1 ") || !strings.HasSuffix(message, "ERROR db timeout") {
+			t.Errorf("Unexpected message %q", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for syslog message")
+	}
+}
+
+func TestJournaldSinkSendsNativeProtocol(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	record := testRecord
+	record.Message = "panic: boom\ngoroutine 1"
+
+	sink := newJournaldSink(LogSink{Address: socket, Labels: map[string]string{"team-name": "core"}})
+	defer sink.Close()
+	if err := sink.Send([]ForwardedLog{record}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected datagram, got %v", err)
+	}
+	datagram := string(buf[:n])
+
+	if !strings.HasPrefix(datagram, "MESSAGE\n\x17\x00\x00\x00\x00\x00\x00\x00panic: boom\ngoroutine 1\n") {
+		t.Errorf("Expected binary-safe multi-line message, got %q", datagram)
+	}
+	for _, field := range []string{"PRIORITY=3\n", "SYSLOG_IDENTIFIER=org-app\n", "ZEN_APP=org/app\n", "ZEN_VERSION=1.2.0\n", "ZEN_TEAM_NAME=core\n"} {
+		if !strings.Contains(datagram, field) {
+			t.Errorf("Expected field %q in %q", field, datagram)
+		}
+	}
+}
+
+func TestLokiSinkPushesStreams(t *testing.T) {
+	var body struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	var path, tenant string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		tenant = r.Header.Get("X-Scope-OrgID")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := newLokiSink(LogSink{Address: server.URL, Headers: map[string]string{"X-Scope-OrgID": "team"}, Labels: map[string]string{"env": "prod"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	later := testRecord
+	later.Time = later.Time.Add(time.Second)
+	later.Message = "ERROR retry failed"
+	zen := ForwardedLog{Time: testRecord.Time, Source: LogSourceZen, Level: LogLevelInfo, Message: "App started"}
+
+	if err := sink.Send([]ForwardedLog{later, zen, testRecord}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if path != lokiPushPath || tenant != "team" {
+		t.Errorf("Expected push to %s with tenant header, got %s %q", lokiPushPath, path, tenant)
+	}
+	if len(body.Streams) != 2 {
+		t.Fatalf("Expected 2 streams, got %+v", body.Streams)
+	}
+
+	app := body.Streams[0]
+	if app.Stream["app"] != "org/app" || app.Stream["version"] != "1.2.0" || app.Stream["level"] != "error" || app.Stream["env"] != "prod" {
+		t.Errorf("Unexpected labels %v", app.Stream)
+	}
+	if len(app.Values) != 2 || app.Values[0][1] != "[web.1] ERROR db timeout" || app.Values[0][0] != "1717211520500000000" {
+		t.Errorf("Expected values sorted by time, got %v", app.Values)
+	}
+	if body.Streams[1].Stream["source"] != LogSourceZen {
+		t.Errorf("Expected zen stream, got %v", body.Streams[1].Stream)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+	sink, _ = newLokiSink(LogSink{Address: failing.URL + "/loki/api/v1/push"})
+	if err := sink.Send([]ForwardedLog{testRecord}); err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const logStreamKeepAlive = 15 * time.Second
+
+func logFilterFromQuery(c *fiber.Ctx) LogFilter {
+	return LogFilter{
+		Type:    c.Query("type"),
+		Version: c.Query("version"),
+		Stream:  c.Query("stream"),
+	}
+}
+
+func logLinesFromQuery(c *fiber.Ctx, fallback int) int {
+	return min(max(c.QueryInt("lines", fallback), 0), maxTailLines)
+}
+
+func handleGetLogs(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	lines, err := tailLogs(app.Key, appLogDir(*app), logFilterFromQuery(c), logLinesFromQuery(c, 100))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to read logs",
+		})
+	}
+
+	return c.JSON(lines)
+}
+
+func handleStreamLogs(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	filter := logFilterFromQuery(c)
+	live, unsubscribe := appUpdater.ProcessManager.SubscribeLogs(app.Key)
+
+	backlog, err := tailLogs(app.Key, appLogDir(*app), filter, logLinesFromQuery(c, 0))
+	if err != nil {
+		unsubscribe()
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to read logs",
+		})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		for _, line := range backlog {
+			writeLogEvent(w, line)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(logStreamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case line, ok := <-live:
+				if !ok {
+					return
+				}
+				if !filter.Matches(line) {
+					continue
+				}
+				writeLogEvent(w, line)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeLogEvent(w *bufio.Writer, line LogLine) {
+	data, _ := json.Marshal(line)
+	fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
+}
+
+func handleSearchLogs(c *fiber.Ctx) error {
+	app, err := appUpdater.FindAppBySlug(c.Params("slug"))
+	if errors.Is(err, ErrAppNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "App not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load setup data",
+		})
+	}
+
+	query, err := logQueryFromRequest(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := logSearch.Search(app.Key, appLogDir(*app), query)
+	if errors.Is(err, ErrInvalidCursor) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid cursor",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to search logs",
+		})
+	}
+
+	return c.JSON(result)
+}
+
+func logQueryFromRequest(c *fiber.Ctx) (LogQuery, error) {
+	query := LogQuery{
+		Filter:   logFilterFromQuery(c),
+		Level:    strings.ToLower(c.Query("level")),
+		Contains: c.Query("q"),
+		Limit:    c.QueryInt("limit", defaultSearchSize),
+		Cursor:   c.Query("cursor"),
+	}
+
+	if query.Level != "" && !validLogLevel(query.Level) {
+		return query, fmt.Errorf("invalid level %q", query.Level)
+	}
+
+	for name, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return query, fmt.Errorf("invalid %s timestamp, expected RFC 3339", name)
+		}
+		*target = t
+	}
+
+	if pattern := c.Query("regex"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return query, fmt.Errorf("invalid regex: %w", err)
+		}
+		query.Pattern = re
+	}
+
+	return query, nil
+}
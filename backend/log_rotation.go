@@ -0,0 +1,279 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogMaxSizeMB  = 10
+	defaultLogMaxAge     = 24 * time.Hour
+	defaultLogRetainMB   = 100
+	defaultLogRetainDays = 14
+
+	logsRoot = "/opt/zen/logs"
+)
+
+type LogConfig struct {
+	MaxSizeMB  int    `json:"maxSizeMb,omitempty" yaml:"maxSizeMb"`
+	MaxAge     string `json:"maxAge,omitempty" yaml:"maxAge"`
+	RetainMB   int    `json:"retainMb,omitempty" yaml:"retainMb"`
+	RetainDays int    `json:"retainDays,omitempty" yaml:"retainDays"`
+}
+
+type logPolicy struct {
+	maxSize    int64
+	maxAge     time.Duration
+	retainSize int64
+	retainAge  time.Duration
+}
+
+func newLogPolicy(config *LogConfig) logPolicy {
+	if config == nil {
+		config = &LogConfig{}
+	}
+
+	return logPolicy{
+		maxSize:    int64(positiveOr(config.MaxSizeMB, defaultLogMaxSizeMB)) << 20,
+		maxAge:     parseDurationOr(config.MaxAge, defaultLogMaxAge),
+		retainSize: int64(positiveOr(config.RetainMB, defaultLogRetainMB)) << 20,
+		retainAge:  time.Duration(positiveOr(config.RetainDays, defaultLogRetainDays)) * 24 * time.Hour,
+	}
+}
+
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func appLogDir(app App) string {
+	return filepath.Join(logsRoot, toSlug(app.Key))
+}
+
+type rotatingLog struct {
+	path     string
+	policy   logPolicy
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	refs     int
+}
+
+func openRotatingLog(path string, policy logPolicy) (*rotatingLog, error) {
+	l := &rotatingLog{path: path, policy: policy}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *rotatingLog) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	l.file = file
+	l.size = info.Size()
+	l.openedAt = info.ModTime()
+	if l.size == 0 {
+		l.openedAt = time.Now()
+	}
+	return nil
+}
+
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if l.size > 0 && (l.size+int64(len(p)) > l.policy.maxSize || time.Since(l.openedAt) > l.policy.maxAge) {
+		if err := l.rotate(); err != nil {
+			subsystemLogger("logs").Error("Failed to rotate log", "path", l.path, "error", err)
+		}
+	}
+
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+func (l *rotatingLog) rotate() error {
+	segment := segmentPath(l.path, time.Now())
+	renameErr := os.Rename(l.path, segment)
+
+	previous := l.file
+	if err := l.open(); err != nil {
+		l.size = 0
+		l.openedAt = time.Now()
+		return err
+	}
+	previous.Close()
+
+	if renameErr != nil {
+		return renameErr
+	}
+
+	policy := l.policy
+	go func() {
+		if err := compressSegment(segment); err != nil {
+			subsystemLogger("logs").Error("Failed to compress log segment", "path", segment, "error", err)
+		}
+		enforceLogRetention(filepath.Dir(l.path), policy)
+	}()
+	return nil
+}
+
+func segmentPath(path string, now time.Time) string {
+	base := fmt.Sprintf("%s-%s", strings.TrimSuffix(path, ".log"), now.UTC().Format("20060102T150405.000"))
+	segment := base + ".log"
+	for i := 1; ; i++ {
+		_, errPlain := os.Stat(segment)
+		_, errCompressed := os.Stat(segment + ".gz")
+		if os.IsNotExist(errPlain) && os.IsNotExist(errCompressed) {
+			return segment
+		}
+		segment = fmt.Sprintf("%s.%d.log", base, i)
+	}
+}
+
+func (l *rotatingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func compressSegment(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dst.Name())
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(dst.Name(), path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func enforceLogRetention(dir string, policy logPolicy) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var segments []os.FileInfo
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".log.gz") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			segments = append(segments, info)
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].ModTime().After(segments[j].ModTime())
+	})
+
+	var total int64
+	for _, segment := range segments {
+		total += segment.Size()
+		if total > policy.retainSize || time.Since(segment.ModTime()) > policy.retainAge {
+			if err := os.Remove(filepath.Join(dir, segment.Name())); err != nil {
+				subsystemLogger("logs").Error("Failed to remove old log", "path", filepath.Join(dir, segment.Name()), "error", err)
+			}
+		}
+	}
+}
+
+type logRegistry struct {
+	mu   sync.Mutex
+	logs map[string]*rotatingLog
+}
+
+func newLogRegistry() *logRegistry {
+	return &logRegistry{logs: make(map[string]*rotatingLog)}
+}
+
+func (r *logRegistry) acquire(path string, policy logPolicy) (*rotatingLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.logs[path]; ok {
+		l.mu.Lock()
+		l.policy = policy
+		l.mu.Unlock()
+		l.refs++
+		return l, nil
+	}
+
+	l, err := openRotatingLog(path, policy)
+	if err != nil {
+		return nil, err
+	}
+	l.refs = 1
+	r.logs[path] = l
+	return l, nil
+}
+
+func (r *logRegistry) release(l *rotatingLog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l.refs--
+	if l.refs > 0 {
+		return
+	}
+
+	delete(r.logs, l.path)
+	l.Close()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingLogRotatesBySizeAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "web-0.log")
+
+	policy := newLogPolicy(nil)
+	policy.maxSize = 64
+	l, err := openRotatingLog(path, policy)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer l.Close()
+
+	line := strings.Repeat("x", 40) + "\n"
+	for i := 0; i < 3; i++ {
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	var segments []string
+	waitFor(t, 2*time.Second, func() bool {
+		segments, _ = filepath.Glob(filepath.Join(dir, "web-0-*.log.gz"))
+		return len(segments) == 2
+	})
+
+	f, _ := os.Open(segments[0])
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected gzip segment, got %v", err)
+	}
+	data, _ := io.ReadAll(gz)
+	if string(data) != line {
+		t.Errorf("Expected segment to contain one line, got %q", data)
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != line {
+		t.Errorf("Expected current log to contain last line, got %q", current)
+	}
+}
+
+func TestRotatingLogRotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "web-0.log")
+
+	l, err := openRotatingLog(path, newLogPolicy(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer l.Close()
+
+	l.Write([]byte("old\n"))
+	l.openedAt = time.Now().Add(-25 * time.Hour)
+	l.Write([]byte("new\n"))
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "new\n" {
+		t.Errorf("Expected rotation after max age, got %q", current)
+	}
+}
+
+func TestRotatingLogKeepsWritingWhenRenameFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "web-0.log")
+
+	l, err := openRotatingLog(path, newLogPolicy(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer l.Close()
+
+	l.Write([]byte("old\n"))
+	os.Remove(path)
+	l.openedAt = time.Now().Add(-25 * time.Hour)
+
+	if _, err := l.Write([]byte("new\n")); err != nil {
+		t.Fatalf("Expected write to succeed after failed rotation, got %v", err)
+	}
+	l.Write([]byte("more\n"))
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "new\nmore\n" {
+		t.Errorf("Expected log reopened after failed rename, got %q", current)
+	}
+}
+
+func TestEnforceLogRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"web-0-1.log.gz", 400, time.Hour},
+		{"web-0-2.log.gz", 400, 2 * time.Hour},
+		{"web-0-3.log.gz", 400, 3 * time.Hour},
+		{"web-0-4.log.gz", 10, 20 * 24 * time.Hour},
+		{"web-0.log", 5000, 4 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		os.WriteFile(path, make([]byte, f.size), 0644)
+		os.Chtimes(path, now.Add(-f.age), now.Add(-f.age))
+	}
+
+	policy := newLogPolicy(nil)
+	policy.retainSize = 1000
+	enforceLogRetention(dir, policy)
+
+	for _, f := range files {
+		_, err := os.Stat(filepath.Join(dir, f.name))
+		kept := err == nil
+		expected := f.name == "web-0-1.log.gz" || f.name == "web-0-2.log.gz" || f.name == "web-0.log"
+		if kept != expected {
+			t.Errorf("%s: expected kept=%v, got %v", f.name, expected, kept)
+		}
+	}
+}
+
+func TestLogRegistrySharesWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "web-0.log")
+	registry := newLogRegistry()
+
+	first, err := registry.acquire(path, newLogPolicy(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, _ := registry.acquire(path, newLogPolicy(nil))
+	if first != second {
+		t.Fatal("Expected the same writer for the same path")
+	}
+
+	registry.release(first)
+	if _, err := second.Write([]byte("still open\n")); err != nil {
+		t.Errorf("Expected writer to stay open, got %v", err)
+	}
+
+	registry.release(second)
+	if _, err := second.Write([]byte("closed\n")); err == nil {
+		t.Error("Expected writer to be closed after last release")
+	}
+}
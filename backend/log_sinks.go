@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSyslogAddress   = "udp://localhost:514"
+	defaultJournaldSocket  = "/run/systemd/journal/socket"
+	lokiPushPath           = "/loki/api/v1/push"
+	syslogFacilityUser     = 1
+	syslogStructuredDataID = "zen@32473"
+	sinkWriteTimeout       = 10 * time.Second
+)
+
+var (
+	syslogParamNameRegex   = regexp.MustCompile(`[^!-~]|[=\]"]`)
+	journaldFieldNameRegex = regexp.MustCompile(`[^A-Z0-9_]`)
+)
+
+func syslogSeverity(level string) int {
+	switch level {
+	case LogLevelError:
+		return 3
+	case LogLevelWarn:
+		return 4
+	case LogLevelDebug:
+		return 7
+	default:
+		return 6
+	}
+}
+
+func logIdentifier(record ForwardedLog) string {
+	if record.Source == LogSourceZen || record.AppKey == "" {
+		return "zen"
+	}
+	return toSlug(record.AppKey)
+}
+
+func recordFields(record ForwardedLog, labels map[string]string) [][2]string {
+	var fields [][2]string
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{key, value})
+		}
+	}
+
+	add("source", record.Source)
+	add("app", record.AppKey)
+	add("version", record.Version)
+	add("type", record.ProcessType)
+	if record.ProcessType != "" {
+		add("replica", strconv.Itoa(record.Replica))
+	}
+	add("stream", record.Stream)
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(key, labels[key])
+	}
+	return fields
+}
+
+type syslogSink struct {
+	network  string
+	address  string
+	hostname string
+	labels   map[string]string
+	conn     net.Conn
+}
+
+func newSyslogSink(sink LogSink) (*syslogSink, error) {
+	address := sink.Address
+	if address == "" {
+		address = defaultSyslogAddress
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog address: %w", err)
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog transport %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("syslog address must include a host")
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	return &syslogSink{
+		network:  u.Scheme,
+		address:  u.Host,
+		hostname: hostname,
+		labels:   sink.Labels,
+	}, nil
+}
+
+func (s *syslogSink) format(record ForwardedLog) string {
+	procID := "-"
+	if record.ProcessType != "" {
+		procID = fmt.Sprintf("%s.%d", record.ProcessType, record.Replica)
+	}
+	msgID := record.Stream
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + syslogStructuredDataID)
+	for _, field := range recordFields(record, s.labels) {
+		name := syslogParamNameRegex.ReplaceAllString(field[0], "_")
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(field[1])
+		fmt.Fprintf(&sd, ` %s="%s"`, name, value)
+	}
+	sd.WriteString("]")
+
+	appName := logIdentifier(record)
+	if len(appName) > 48 {
+		appName = appName[:48]
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		syslogFacilityUser*8+syslogSeverity(record.Level),
+		record.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname,
+		appName,
+		procID,
+		msgID,
+		sd.String(),
+		record.Message,
+	)
+}
+
+func (s *syslogSink) Send(batch []ForwardedLog) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, sinkWriteTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(sinkWriteTimeout))
+	for _, record := range batch {
+		message := s.format(record)
+		if s.network == "tcp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		if _, err := io.WriteString(s.conn, message); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+type journaldSink struct {
+	socket string
+	labels map[string]string
+	conn   *net.UnixConn
+}
+
+func newJournaldSink(sink LogSink) *journaldSink {
+	socket := sink.Address
+	if socket == "" {
+		socket = defaultJournaldSocket
+	}
+	return &journaldSink{socket: socket, labels: sink.Labels}
+}
+
+func (s *journaldSink) encode(record ForwardedLog) []byte {
+	var buf bytes.Buffer
+	write := func(key, value string) {
+		if !strings.Contains(value, "\n") {
+			buf.WriteString(key + "=" + value + "\n")
+			return
+		}
+		buf.WriteString(key + "\n")
+		binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value + "\n")
+	}
+
+	write("MESSAGE", record.Message)
+	write("PRIORITY", strconv.Itoa(syslogSeverity(record.Level)))
+	write("SYSLOG_IDENTIFIER", logIdentifier(record))
+	write("SYSLOG_TIMESTAMP", record.Time.UTC().Format(time.RFC3339Nano))
+	for _, field := range recordFields(record, s.labels) {
+		name := "ZEN_" + journaldFieldNameRegex.ReplaceAllString(strings.ToUpper(field[0]), "_")
+		write(name, field[1])
+	}
+	return buf.Bytes()
+}
+
+func (s *journaldSink) Send(batch []ForwardedLog) error {
+	if s.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: s.socket, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	for _, record := range batch {
+		if _, err := s.conn.Write(s.encode(record)); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *journaldSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+type lokiSink struct {
+	url     string
+	labels  map[string]string
+	headers map[string]string
+	client  HTTPClient
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func newLokiSink(sink LogSink) (*lokiSink, error) {
+	u, err := url.Parse(sink.Address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("loki sink requires an http(s) address")
+	}
+	if !strings.HasSuffix(u.Path, lokiPushPath) {
+		u.Path = strings.TrimSuffix(u.Path, "/") + lokiPushPath
+	}
+
+	return &lokiSink{
+		url:     u.String(),
+		labels:  sink.Labels,
+		headers: sink.Headers,
+		client:  &http.Client{Timeout: sinkWriteTimeout},
+	}, nil
+}
+
+func (s *lokiSink) payload(batch []ForwardedLog) ([]byte, error) {
+	streams := make(map[string]*lokiStream)
+	var order []string
+
+	for _, record := range batch {
+		labels := map[string]string{"level": record.Level}
+		for _, field := range recordFields(record, s.labels) {
+			if field[0] != "replica" {
+				labels[field[0]] = field[1]
+			}
+		}
+
+		key, _ := json.Marshal(labels)
+		stream, ok := streams[string(key)]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			streams[string(key)] = stream
+			order = append(order, string(key))
+		}
+
+		line := record.Message
+		if record.ProcessType != "" {
+			line = fmt.Sprintf("[%s.%d] %s", record.ProcessType, record.Replica, line)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(record.Time.UnixNano(), 10), line})
+	}
+
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range order {
+		stream := streams[key]
+		sort.SliceStable(stream.Values, func(i, j int) bool {
+			a, _ := strconv.ParseInt(stream.Values[i][0], 10, 64)
+			b, _ := strconv.ParseInt(stream.Values[j][0], 10, 64)
+			return a < b
+		})
+		body.Streams = append(body.Streams, stream)
+	}
+	return json.Marshal(body)
+}
+
+func (s *lokiSink) Send(batch []ForwardedLog) error {
+	data, err := s.payload(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("loki push failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *lokiSink) Close() error {
+	return nil
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+
+	logIndexInterval  = 256
+	logClockSkew      = time.Second
+	defaultSearchSize = 100
+	maxSearchSize     = 1000
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	logFilePattern       = regexp.MustCompile(`^(.+)-(\d+)(-\d{8}T\d{6}\.\d{3}(\.\d+)?)?\.log(\.gz)?$`)
+	structuredLevelRegex = regexp.MustCompile(`(?i)"?\b(?:level|lvl|severity)"?\s*[:=]\s*"?([a-z]+)`)
+	errorLevelRegex      = regexp.MustCompile(`(?i)\b(error|fatal|panic|critical|exception)\b`)
+	warnLevelRegex       = regexp.MustCompile(`(?i)\b(warn|warning|deprecated)\b`)
+	debugLevelRegex      = regexp.MustCompile(`(?i)\b(debug|trace)\b`)
+
+	logLevelRanks = map[string]int{
+		LogLevelDebug: 0,
+		LogLevelInfo:  1,
+		LogLevelWarn:  2,
+		LogLevelError: 3,
+	}
+)
+
+var logSearch = newLogStore()
+
+type LogQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Filter   LogFilter
+	Level    string
+	Contains string
+	Pattern  *regexp.Regexp
+	Limit    int
+	Cursor   string
+}
+
+type LogMatch struct {
+	LogLine
+	Level string `json:"level"`
+
+	file   string
+	offset int64
+}
+
+type LogSearchResult struct {
+	Lines      []LogMatch `json:"lines"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+type logCursor struct {
+	Time   int64  `json:"t"`
+	File   string `json:"f"`
+	Offset int64  `json:"o"`
+}
+
+func (m LogMatch) after(cursor *logCursor) bool {
+	if cursor == nil {
+		return true
+	}
+	t := m.Time.UnixNano()
+	if t != cursor.Time {
+		return t > cursor.Time
+	}
+	if m.file != cursor.File {
+		return m.file > cursor.File
+	}
+	return m.offset > cursor.Offset
+}
+
+func (m LogMatch) before(other LogMatch) bool {
+	if !m.Time.Equal(other.Time) {
+		return m.Time.Before(other.Time)
+	}
+	if m.file != other.file {
+		return m.file < other.file
+	}
+	return m.offset < other.offset
+}
+
+func encodeLogCursor(m LogMatch) string {
+	data, _ := json.Marshal(logCursor{Time: m.Time.UnixNano(), File: m.file, Offset: m.offset})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeLogCursor(value string) (*logCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor logCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.File == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func detectLogLevel(message string) string {
+	if match := structuredLevelRegex.FindStringSubmatch(message); match != nil {
+		switch strings.ToLower(match[1]) {
+		case "debug", "trace":
+			return LogLevelDebug
+		case "info", "notice":
+			return LogLevelInfo
+		case "warn", "warning":
+			return LogLevelWarn
+		case "error", "err", "fatal", "panic", "critical", "crit":
+			return LogLevelError
+		}
+	}
+
+	switch {
+	case errorLevelRegex.MatchString(message):
+		return LogLevelError
+	case warnLevelRegex.MatchString(message):
+		return LogLevelWarn
+	case debugLevelRegex.MatchString(message):
+		return LogLevelDebug
+	}
+	return LogLevelInfo
+}
+
+func validLogLevel(level string) bool {
+	_, ok := logLevelRanks[level]
+	return ok
+}
+
+func (q LogQuery) matches(m LogMatch) bool {
+	if !q.Filter.Matches(m.LogLine) {
+		return false
+	}
+	if !q.Since.IsZero() && m.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && m.Time.After(q.Until) {
+		return false
+	}
+	if q.Level != "" && logLevelRanks[m.Level] < logLevelRanks[q.Level] {
+		return false
+	}
+	if q.Contains != "" && !strings.Contains(strings.ToLower(m.Message), strings.ToLower(q.Contains)) {
+		return false
+	}
+	if q.Pattern != nil && !q.Pattern.MatchString(m.Message) {
+		return false
+	}
+	return true
+}
+
+type logCheckpoint struct {
+	time   time.Time
+	offset int64
+}
+
+type logFileIndex struct {
+	file        os.FileInfo
+	size        int64
+	modTime     time.Time
+	indexed     int64
+	lines       int
+	first       time.Time
+	last        time.Time
+	checkpoints []logCheckpoint
+}
+
+func (idx *logFileIndex) overlaps(since, until time.Time) bool {
+	if idx.lines == 0 {
+		return false
+	}
+	if !since.IsZero() && idx.last.Before(since.Add(-logClockSkew)) {
+		return false
+	}
+	if !until.IsZero() && idx.first.After(until.Add(logClockSkew)) {
+		return false
+	}
+	return true
+}
+
+func (idx *logFileIndex) seekOffset(since time.Time) int64 {
+	if since.IsZero() {
+		return 0
+	}
+
+	limit := since.Add(-logClockSkew)
+	i := sort.Search(len(idx.checkpoints), func(i int) bool {
+		return !idx.checkpoints[i].time.Before(limit)
+	})
+	if i == 0 {
+		return 0
+	}
+	return idx.checkpoints[i-1].offset
+}
+
+func (idx *logFileIndex) record(t time.Time, offset int64) {
+	if idx.lines == 0 || t.Before(idx.first) {
+		idx.first = t
+	}
+	if t.After(idx.last) {
+		idx.last = t
+	}
+	if idx.lines%logIndexInterval == 0 {
+		idx.checkpoints = append(idx.checkpoints, logCheckpoint{time: t, offset: offset})
+	}
+	idx.lines++
+}
+
+type logStore struct {
+	mu    sync.Mutex
+	files map[string]*logFileIndex
+}
+
+func newLogStore() *logStore {
+	return &logStore{files: make(map[string]*logFileIndex)}
+}
+
+func (s *logStore) Search(appKey, dir string, query LogQuery) (*LogSearchResult, error) {
+	cursor, err := decodeLogCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchSize
+	}
+	query.Limit = min(query.Limit, maxSearchSize)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &LogSearchResult{Lines: []LogMatch{}}, nil
+		}
+		return nil, err
+	}
+
+	since := query.Since
+	if cursor != nil && time.Unix(0, cursor.Time).After(since) {
+		since = time.Unix(0, cursor.Time)
+	}
+
+	present := make(map[string]bool)
+	var matches []LogMatch
+	for _, entry := range entries {
+		match := logFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		present[path] = true
+		if query.Filter.Type != "" && match[1] != query.Filter.Type {
+			continue
+		}
+
+		idx, err := s.index(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if !idx.overlaps(since, query.Until) {
+			continue
+		}
+
+		fileMatches, err := s.searchFile(appKey, path, idx, query, since, cursor)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		matches = append(matches, fileMatches...)
+	}
+	s.prune(dir, present)
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].before(matches[j])
+	})
+
+	result := &LogSearchResult{Lines: []LogMatch{}}
+	if len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+		result.NextCursor = encodeLogCursor(matches[len(matches)-1])
+	}
+	result.Lines = append(result.Lines, matches...)
+	return result, nil
+}
+
+func (s *logStore) searchFile(appKey, path string, idx *logFileIndex, query LogQuery, since time.Time, cursor *logCursor) ([]LogMatch, error) {
+	reader, closeFn, offset, err := openLogReader(path, idx.seekOffset(since))
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	name := filepath.Base(path)
+	var matches []LogMatch
+	buf := bufio.NewReaderSize(reader, 64*1024)
+	for {
+		text, err := buf.ReadString('\n')
+		if len(text) > 0 && strings.HasSuffix(text, "\n") {
+			lineOffset := offset
+			offset += int64(len(text))
+
+			line, ok := parseLogLine(appKey, strings.TrimSuffix(text, "\n"))
+			if ok {
+				if !query.Until.IsZero() && line.Time.After(query.Until.Add(logClockSkew)) {
+					break
+				}
+
+				m := LogMatch{LogLine: line, Level: detectLogLevel(line.Message), file: name, offset: lineOffset}
+				if m.after(cursor) && query.matches(m) {
+					matches = append(matches, m)
+					if len(matches) > query.Limit {
+						break
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+func openLogReader(path string, offset int64) (io.Reader, func(), int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, 0, err
+		}
+		return gz, func() { gz.Close(); f.Close() }, 0, nil
+	}
+
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, nil, 0, err
+		}
+	}
+	return f, func() { f.Close() }, offset, nil
+}
+
+func (s *logStore) index(path string) (*logFileIndex, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx, ok := s.files[path]
+	if ok && idx.size == info.Size() && idx.modTime.Equal(info.ModTime()) {
+		return idx, nil
+	}
+
+	compressed := strings.HasSuffix(path, ".gz")
+	if !ok || compressed || !os.SameFile(idx.file, info) || info.Size() < idx.indexed {
+		idx = &logFileIndex{}
+	} else {
+		copied := *idx
+		copied.checkpoints = append([]logCheckpoint{}, idx.checkpoints...)
+		idx = &copied
+	}
+
+	if err := idx.extend(path, compressed); err != nil {
+		return nil, err
+	}
+	idx.file = info
+	idx.size = info.Size()
+	idx.modTime = info.ModTime()
+	s.files[path] = idx
+	return idx, nil
+}
+
+func (idx *logFileIndex) extend(path string, compressed bool) error {
+	reader, closeFn, offset, err := openLogReader(path, idx.indexed)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	buf := bufio.NewReaderSize(reader, 64*1024)
+	for {
+		text, err := buf.ReadString('\n')
+		if strings.HasSuffix(text, "\n") {
+			if line, ok := parseLogLine("", strings.TrimSuffix(text, "\n")); ok {
+				idx.record(line.Time, offset)
+			}
+			offset += int64(len(text))
+			if !compressed {
+				idx.indexed = offset
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *logStore) prune(dir string, present map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for path := range s.files {
+		if filepath.Dir(path) == dir && !present[path] {
+			delete(s.files, path)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func writeLogFile(t *testing.T, path string, lines ...LogLine) {
+	t.Helper()
+
+	var data []byte
+	for _, line := range lines {
+		data = append(data, line.format()...)
+	}
+
+	if filepath.Ext(path) != ".gz" {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write(data)
+	gz.Close()
+	f.Close()
+}
+
+func TestDetectLogLevel(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"GET /health 200", LogLevelInfo},
+		{"ERROR failed to connect to database", LogLevelError},
+		{"panic: runtime error: index out of range", LogLevelError},
+		{"0 errors found", LogLevelInfo},
+		{"Warning: config option is deprecated", LogLevelWarn},
+		{`{"level":"debug","msg":"cache miss on error page"}`, LogLevelDebug},
+		{"time=2024-06-01 level=WARN msg=slow", LogLevelWarn},
+		{"[DEBUG] cache warmed", LogLevelDebug},
+	}
+
+	for _, tt := range tests {
+		if got := detectLogLevel(tt.message); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.message, tt.expected, got)
+		}
+	}
+}
+
+func TestLogStoreSearch(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	writeLogFile(t, filepath.Join(dir, "web-0-20240601T030500.000.log.gz"),
+		LogLine{Time: at(0), Type: "web", Version: "1.0.0", Stream: StreamStdout, Message: "starting"},
+		LogLine{Time: at(4), Type: "web", Version: "1.0.0", Stream: StreamStderr, Message: "ERROR db timeout"},
+	)
+	writeLogFile(t, filepath.Join(dir, "web-0.log"),
+		LogLine{Time: at(6), Type: "web", Version: "1.1.0", Stream: StreamStdout, Message: "GET /orders 200"},
+		LogLine{Time: at(12), Type: "web", Version: "1.1.0", Stream: StreamStderr, Message: "ERROR order 42 failed"},
+	)
+	writeLogFile(t, filepath.Join(dir, "worker-0.log"),
+		LogLine{Time: at(11), Type: "worker", Version: "1.1.0", Stream: StreamStdout, Message: "warning: queue backlog"},
+		LogLine{Time: at(12), Type: "worker", Version: "1.1.0", Stream: StreamStdout, Message: "processed order 42"},
+	)
+
+	store := newLogStore()
+	messages := func(query LogQuery) []string {
+		t.Helper()
+		result, err := store.Search("org/app", dir, query)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var got []string
+		for _, line := range result.Lines {
+			got = append(got, line.Message)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		query    LogQuery
+		expected []string
+	}{
+		{"all", LogQuery{}, []string{"starting", "ERROR db timeout", "GET /orders 200", "warning: queue backlog", "ERROR order 42 failed", "processed order 42"}},
+		{"time range", LogQuery{Since: at(10), Until: at(11)}, []string{"warning: queue backlog"}},
+		{"level", LogQuery{Level: LogLevelWarn}, []string{"ERROR db timeout", "warning: queue backlog", "ERROR order 42 failed"}},
+		{"substring", LogQuery{Contains: "ORDER 42"}, []string{"ERROR order 42 failed", "processed order 42"}},
+		{"regex", LogQuery{Pattern: regexp.MustCompile(`^GET /\w+ 2\d\d$`)}, []string{"GET /orders 200"}},
+		{"type", LogQuery{Filter: LogFilter{Type: "web", Stream: StreamStderr}}, []string{"ERROR db timeout", "ERROR order 42 failed"}},
+		{"compressed segment", LogQuery{Until: at(5), Level: LogLevelError}, []string{"ERROR db timeout"}},
+	}
+
+	for _, tt := range tests {
+		got := messages(tt.query)
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestLogStorePaginates(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	var web, worker []LogLine
+	for i := 0; i < 5; i++ {
+		ts := base.Add(time.Duration(i) * time.Second)
+		web = append(web, LogLine{Time: ts, Type: "web", Version: "1.0.0", Stream: StreamStdout, Message: fmt.Sprintf("web %d", i)})
+		worker = append(worker, LogLine{Time: ts, Type: "worker", Version: "1.0.0", Stream: StreamStdout, Message: fmt.Sprintf("worker %d", i)})
+	}
+	writeLogFile(t, filepath.Join(dir, "web-0.log"), web...)
+	writeLogFile(t, filepath.Join(dir, "worker-0.log"), worker...)
+
+	store := newLogStore()
+	var pages [][]string
+	cursor := ""
+	for {
+		result, err := store.Search("org/app", dir, LogQuery{Limit: 4, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var page []string
+		for _, line := range result.Lines {
+			page = append(page, line.Message)
+		}
+		pages = append(pages, page)
+
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	expected := "[[web 0 worker 0 web 1 worker 1] [web 2 worker 2 web 3 worker 3] [web 4 worker 4]]"
+	if fmt.Sprint(pages) != expected {
+		t.Errorf("Expected pages %s, got %v", expected, pages)
+	}
+
+	if _, err := store.Search("org/app", dir, LogQuery{Cursor: "not-a-cursor"}); err != ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestLogStoreIndexesIncrementally(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "web-0.log")
+	base := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	var lines []LogLine
+	for i := 0; i < 3*logIndexInterval; i++ {
+		lines = append(lines, LogLine{Time: base.Add(time.Duration(i) * time.Minute), Type: "web", Stream: StreamStdout, Version: "1.0.0", Message: fmt.Sprintf("line %d", i)})
+	}
+	writeLogFile(t, path, lines...)
+
+	store := newLogStore()
+	since := base.Add(time.Duration(2*logIndexInterval+10) * time.Minute)
+	result, err := store.Search("org/app", dir, LogQuery{Since: since, Limit: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Lines) != 1 || result.Lines[0].Message != fmt.Sprintf("line %d", 2*logIndexInterval+10) {
+		t.Errorf("Unexpected result %+v", result.Lines)
+	}
+
+	idx := store.files[path]
+	if len(idx.checkpoints) != 3 || idx.seekOffset(since) == 0 {
+		t.Errorf("Expected checkpoints to allow seeking, got %+v", idx.checkpoints)
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(LogLine{Time: base.Add(24 * time.Hour), Type: "web", Stream: StreamStdout, Version: "1.0.0", Message: "appended"}.format())
+	f.WriteString("partial")
+	f.Close()
+
+	result, _ = store.Search("org/app", dir, LogQuery{Contains: "appended"})
+	if len(result.Lines) != 1 {
+		t.Errorf("Expected appended line to be found, got %+v", result.Lines)
+	}
+	if idx := store.files[path]; idx.lines != 3*logIndexInterval+1 || !idx.last.Equal(base.Add(24*time.Hour)) {
+		t.Errorf("Expected index to be extended, got %d lines ending %v", idx.lines, idx.last)
+	}
+}
+
+func TestLogStoreRebuildsIndexAfterRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "web-0.log")
+	base := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	writeLogFile(t, path,
+		LogLine{Time: base, Type: "web", Stream: StreamStdout, Version: "1.0.0", Message: "old"},
+	)
+
+	store := newLogStore()
+	if result, _ := store.Search("org/app", dir, LogQuery{}); len(result.Lines) != 1 {
+		t.Fatalf("Expected one line before rotation, got %+v", result.Lines)
+	}
+
+	os.Rename(path, filepath.Join(dir, "web-0-20240601T030000.000.log"))
+	writeLogFile(t, path,
+		LogLine{Time: base.Add(time.Hour), Type: "web", Stream: StreamStdout, Version: "1.0.0", Message: "first after rotation"},
+		LogLine{Time: base.Add(2 * time.Hour), Type: "web", Stream: StreamStdout, Version: "1.0.0", Message: "second after rotation"},
+	)
+
+	result, err := store.Search("org/app", dir, LogQuery{Since: base.Add(30 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Lines) != 2 || result.Lines[0].Message != "first after rotation" {
+		t.Errorf("Expected both lines of the new file, got %+v", result.Lines)
+	}
+	if idx := store.files[path]; idx.lines != 2 || !idx.first.Equal(base.Add(time.Hour)) {
+		t.Errorf("Expected index rebuilt for the new file, got %d lines starting %v", idx.lines, idx.first)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+
+	logSubscriberBuffer = 256
+	maxLogLineLength    = 64 * 1024
+	maxTailLines        = 5000
+)
+
+var currentLogFilePattern = regexp.MustCompile(`^(.+)-(\d+)\.log$`)
+
+type LogLine struct {
+	Time    time.Time `json:"time"`
+	AppKey  string    `json:"appKey"`
+	Type    string    `json:"type"`
+	Replica int       `json:"replica"`
+	Version string    `json:"version"`
+	Stream  string    `json:"stream"`
+	Message string    `json:"message"`
+}
+
+type LogFilter struct {
+	Type    string
+	Version string
+	Stream  string
+}
+
+func (f LogFilter) Matches(line LogLine) bool {
+	return (f.Type == "" || f.Type == line.Type) &&
+		(f.Version == "" || f.Version == line.Version) &&
+		(f.Stream == "" || f.Stream == line.Stream)
+}
+
+func (l LogLine) format() string {
+	return strings.Join([]string{
+		l.Time.UTC().Format(time.RFC3339Nano),
+		l.Stream,
+		l.Type + "." + strconv.Itoa(l.Replica),
+		l.Version,
+		l.Message,
+	}, " ") + "\n"
+}
+
+func parseLogLine(appKey, text string) (LogLine, bool) {
+	fields := strings.SplitN(text, " ", 5)
+	if len(fields) < 4 {
+		return LogLine{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return LogLine{}, false
+	}
+
+	processType, replica, ok := strings.Cut(fields[2], ".")
+	if !ok {
+		return LogLine{}, false
+	}
+	n, err := strconv.Atoi(replica)
+	if err != nil {
+		return LogLine{}, false
+	}
+
+	line := LogLine{
+		Time:    t,
+		AppKey:  appKey,
+		Type:    processType,
+		Replica: n,
+		Version: fields[3],
+		Stream:  fields[1],
+	}
+	if len(fields) == 5 {
+		line.Message = fields[4]
+	}
+	return line, true
+}
+
+type logHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan LogLine]struct{}
+}
+
+func newLogHub() *logHub {
+	return &logHub{subscribers: make(map[string]map[chan LogLine]struct{})}
+}
+
+func (h *logHub) Subscribe(appKey string) (<-chan LogLine, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan LogLine, logSubscriberBuffer)
+	if h.subscribers[appKey] == nil {
+		h.subscribers[appKey] = make(map[chan LogLine]struct{})
+	}
+	h.subscribers[appKey][ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			delete(h.subscribers[appKey], ch)
+			if len(h.subscribers[appKey]) == 0 {
+				delete(h.subscribers, appKey)
+			}
+			close(ch)
+		})
+	}
+}
+
+func (h *logHub) Publish(line LogLine) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[line.AppKey] {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+type lineWriter struct {
+	inst   *processInstance
+	stream string
+	hub    *logHub
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+
+	if len(w.buf) > maxLogLineLength {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func (w *lineWriter) emit(message string) {
+	line := LogLine{
+		Time:    time.Now(),
+		AppKey:  w.inst.appKey,
+		Type:    w.inst.spec.Type,
+		Replica: w.inst.replica,
+		Version: w.inst.version,
+		Stream:  w.stream,
+		Message: strings.TrimSuffix(message, "\r"),
+	}
+
+	w.inst.output.Write([]byte(line.format()))
+	w.hub.Publish(line)
+	logSinks.ForwardLine(line)
+}
+
+func tailLogs(appKey, dir string, filter LogFilter, n int) ([]LogLine, error) {
+	if n <= 0 {
+		return []LogLine{}, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []LogLine{}, nil
+		}
+		return nil, err
+	}
+
+	var lines []LogLine
+	for _, entry := range entries {
+		match := currentLogFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil || (filter.Type != "" && match[1] != filter.Type) {
+			continue
+		}
+
+		fileLines, err := tailLogFile(appKey, filepath.Join(dir, entry.Name()), filter, n)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fileLines...)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time.Before(lines[j].Time)
+	})
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+func tailLogFile(appKey, path string, filter LogFilter, n int) ([]LogLine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := make([]LogLine, 0, n)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*maxLogLineLength)
+	for scanner.Scan() {
+		line, ok := parseLogLine(appKey, scanner.Text())
+		if !ok || !filter.Matches(line) {
+			continue
+		}
+		if len(lines) == n {
+			lines = lines[1:]
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
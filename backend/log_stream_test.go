@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogLineRoundTrip(t *testing.T) {
+	line := LogLine{
+		Time:    time.Date(2024, 6, 1, 3, 0, 0, 123000000, time.UTC),
+		AppKey:  "org/app",
+		Type:    "web",
+		Replica: 2,
+		Version: "1.1.0",
+		Stream:  StreamStderr,
+		Message: "listening on :8080  ",
+	}
+
+	parsed, ok := parseLogLine("org/app", line.format()[:len(line.format())-1])
+	if !ok {
+		t.Fatal("Expected line to parse")
+	}
+	if parsed != line {
+		t.Errorf("Expected %+v, got %+v", line, parsed)
+	}
+
+	if _, ok := parseLogLine("org/app", "plain output without metadata"); ok {
+		t.Error("Expected unstructured line to be rejected")
+	}
+}
+
+func TestTailLogsMergesAndFilters(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	write := func(name string, lines ...LogLine) {
+		var data []byte
+		for _, line := range lines {
+			data = append(data, line.format()...)
+		}
+		os.WriteFile(filepath.Join(dir, name), data, 0644)
+	}
+
+	write("web-0.log",
+		LogLine{Time: base, Type: "web", Version: "1.0.0", Stream: StreamStdout, Message: "one"},
+		LogLine{Time: base.Add(2 * time.Second), Type: "web", Version: "1.1.0", Stream: StreamStderr, Message: "three"},
+	)
+	write("worker-0.log",
+		LogLine{Time: base.Add(time.Second), Type: "worker", Version: "1.1.0", Stream: StreamStdout, Message: "two"},
+		LogLine{Time: base.Add(3 * time.Second), Type: "worker", Version: "1.1.0", Stream: StreamStdout, Message: "four"},
+	)
+	write("web-0-20240601T020000.000.log",
+		LogLine{Time: base.Add(-time.Hour), Type: "web", Version: "1.0.0", Stream: StreamStdout, Message: "rotated"},
+	)
+
+	lines, err := tailLogs("org/app", dir, LogFilter{}, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(lines) != 3 || lines[0].Message != "two" || lines[2].Message != "four" {
+		t.Errorf("Expected last 3 lines in order, got %+v", lines)
+	}
+
+	lines, _ = tailLogs("org/app", dir, LogFilter{Version: "1.1.0", Stream: StreamStderr}, 10)
+	if len(lines) != 1 || lines[0].Message != "three" {
+		t.Errorf("Expected filtered stderr line, got %+v", lines)
+	}
+
+	lines, _ = tailLogs("org/app", dir, LogFilter{Type: "worker"}, 10)
+	if len(lines) != 2 {
+		t.Errorf("Expected 2 worker lines, got %+v", lines)
+	}
+}
+
+func TestLogHubUnsubscribe(t *testing.T) {
+	hub := newLogHub()
+	lines, unsubscribe := hub.Subscribe("org/app")
+
+	hub.Publish(LogLine{AppKey: "org/app", Message: "hello"})
+	hub.Publish(LogLine{AppKey: "other/app", Message: "ignored"})
+
+	if line := <-lines; line.Message != "hello" {
+		t.Errorf("Expected 'hello', got '%s'", line.Message)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-lines; ok {
+		t.Error("Expected channel to be closed")
+	}
+	hub.Publish(LogLine{AppKey: "org/app", Message: "after"})
+}
+
+func TestProcessManagerPublishesOutputLines(t *testing.T) {
+	dir := t.TempDir()
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	lines, unsubscribe := pm.SubscribeLogs("org/app")
+	defer unsubscribe()
+
+	err := pm.Start("org/app", "1.0.0", dir, []ProcessSpec{
+		{Type: "web", Command: "echo out; printf partial; echo err >&2; sleep 30", LogDir: dir, LogPolicy: newLogPolicy(nil)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	received := make(map[string]string)
+	timeout := time.After(2 * time.Second)
+	for len(received) < 2 {
+		select {
+		case line := <-lines:
+			received[line.Message] = line.Stream
+			if line.Version != "1.0.0" || line.Type != "web" || line.Time.IsZero() {
+				t.Errorf("Unexpected line metadata %+v", line)
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for lines, got %v", received)
+		}
+	}
+
+	if received["out"] != StreamStdout || received["err"] != StreamStderr {
+		t.Errorf("Expected stdout and stderr lines, got %v", received)
+	}
+
+	pm.Stop("org/app")
+	tail, _ := tailLogs("org/app", dir, LogFilter{}, 10)
+	if len(tail) != 3 || tail[2].Message != "partial" {
+		t.Errorf("Expected partial line flushed on exit, got %+v", tail)
+	}
+}
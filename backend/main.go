@@ -2,7 +2,7 @@ package main
 
 import (
 	"embed"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,29 +10,50 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 //go:embed frontend/dist
 var distFS embed.FS
 
+var buildVersion = "dev"
+
 func main() {
+	if err := configureLogging(os.Getenv("ZEN_LOG_LEVEL"), os.Getenv("ZEN_LOG_FORMAT")); err != nil {
+		slog.Error("Failed to configure logging", "error", err)
+		os.Exit(1)
+	}
+
 	params, err := loadOrCreateParams()
 	if err != nil {
-		log.Fatal("Failed to load params:", err)
+		slog.Error("Failed to load params", "error", err)
+		os.Exit(1)
 	}
 	jwtSecret = []byte(params.JWTSecret)
 
-	appUpdater := NewDefaultAppUpdater("/opt/zen/data/setup.json")
+	credentialService, err = NewDefaultCredentialService(params.EncryptionKey)
+	if err != nil {
+		slog.Error("Failed to initialize credentials", "error", err)
+		os.Exit(1)
+	}
+
+	appUpdater = NewDefaultAppUpdater("/opt/zen/data/setup.json", credentialService)
+	metrics.collect(collectZenMetrics)
+	metrics.collect(appUpdater.collectMetrics)
+	metricsToken = os.Getenv("ZEN_METRICS_TOKEN")
 	go appUpdater.Start()
 
+	webhookService = NewWebhookService(&osFileSystem{}, appUpdater, "/opt/zen/data/setup.json")
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-sigChan
-		log.Println("Shutting down, stopping all managed apps...")
+		slog.Info("Shutting down, stopping all managed apps")
+		appUpdater.proxies.CloseAll()
 		appUpdater.ProcessManager.StopAll()
+		logSinks.Close()
+		events.Close()
 		os.Exit(0)
 	}()
 
@@ -40,14 +61,48 @@ func main() {
 		AppName: "Zen",
 	})
 
-	app.Use(logger.New())
+	app.Use(requestLogger)
+
+	app.Get("/metrics", handleMetrics)
 
 	api := app.Group("/api")
 	api.Get("/health", handleHealth)
+	api.Get("/ready", handleReady)
 	api.Get("/check", handleCheck)
 	api.Post("/setup", handleSetup)
 	api.Post("/login", handleLogin)
 	api.Post("/logout", handleLogout)
+	api.Post("/webhooks/github", handleGitHubWebhook)
+
+	api.Get("/credentials", requireAuth, handleListCredentials)
+	api.Post("/credentials", requireAuth, handleSaveCredential)
+	api.Delete("/credentials/:name", requireAuth, handleDeleteCredential)
+
+	api.Get("/apps/pending", requireAuth, handleListPendingReleases)
+	api.Get("/usage", requireAuth, handleUsageSummary)
+	api.Post("/apps/:slug/check", requireAuth, handleCheckApp)
+	api.Get("/apps/:slug/deployments", requireAuth, handleListDeployments)
+	api.Get("/apps/:slug/processes", requireAuth, handleListProcesses)
+	api.Get("/apps/:slug/logs", requireAuth, handleGetLogs)
+	api.Get("/apps/:slug/logs/stream", requireAuth, handleStreamLogs)
+	api.Get("/apps/:slug/logs/search", requireAuth, handleSearchLogs)
+	api.Get("/apps/:slug/usage", requireAuth, handleGetUsage)
+	api.Get("/apps/:slug/jobs/runs", requireAuth, handleListJobRuns)
+	api.Post("/apps/:slug/jobs/:job/run", requireAuth, handleRunJob)
+	api.Post("/apps/:slug/releases/:version/approve", requireAuth, handleApproveRelease)
+	api.Post("/apps/:slug/releases/:version/reject", requireAuth, handleRejectRelease)
+
+	api.Get("/system/logs", requireAuth, handleListSystemLogs)
+	api.Get("/system/log-level", requireAuth, handleGetLogLevel)
+	api.Put("/system/log-level", requireAuth, handleSetLogLevel)
+	api.Get("/system/log-sinks", requireAuth, handleListLogSinks)
+
+	api.Get("/events", requireAuth, handleListEvents)
+	api.Get("/events/stream", requireAuth, handleStreamEvents)
+	api.Get("/events/webhooks", requireAuth, handleListEventWebhooks)
+
+	api.Get("/alerts", requireAuth, handleListAlerts)
+	api.Post("/alerts/notifiers/:name/test", requireAuth, handleTestNotifier)
 
 	httpFS := http.FS(distFS)
 	app.Use("/", filesystem.New(filesystem.Config{
@@ -56,8 +111,9 @@ func main() {
 		Browse:     false,
 	}))
 
-	log.Printf("Server starting on port 8888")
+	slog.Info("Server starting", "port", 8888)
 	if err := app.Listen(":8888"); err != nil {
-		log.Fatal(err)
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
 	}
 }
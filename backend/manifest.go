@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var manifestFiles = []string{"zen.yaml", "zen.yml", "zen.json"}
+
+type Manifest struct {
+	Command     string                 `json:"command,omitempty" yaml:"command"`
+	Processes   map[string]ProcessType `json:"processes,omitempty" yaml:"processes"`
+	HealthCheck *HealthCheck           `json:"healthCheck,omitempty" yaml:"healthCheck"`
+	Env         map[string]string      `json:"env,omitempty" yaml:"env"`
+	Hooks       *Hooks                 `json:"hooks,omitempty" yaml:"hooks"`
+	Jobs        []Job                  `json:"jobs,omitempty" yaml:"jobs"`
+	ZenVersion  string                 `json:"zenVersion,omitempty" yaml:"zenVersion"`
+}
+
+func (au *AppUpdater) cachedManifest(installPath string) (*Manifest, error) {
+	if cached, ok := au.manifests.Load(installPath); ok {
+		return cached.(*Manifest), nil
+	}
+
+	manifest, err := au.readManifest(installPath)
+	if err != nil {
+		return nil, err
+	}
+	au.manifests.Store(installPath, manifest)
+	return manifest, nil
+}
+
+func (au *AppUpdater) readManifest(installPath string) (*Manifest, error) {
+	var manifest *Manifest
+	for _, name := range manifestFiles {
+		data, err := au.fs.ReadFile(filepath.Join(installPath, name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		manifest = &Manifest{}
+		if filepath.Ext(name) == ".json" {
+			err = json.Unmarshal(data, manifest)
+		} else {
+			err = yaml.Unmarshal(data, manifest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		break
+	}
+
+	data, err := au.fs.ReadFile(filepath.Join(installPath, "Procfile"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return manifest, nil
+		}
+		return nil, err
+	}
+
+	processes, err := parseProcfile(data)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		manifest = &Manifest{}
+	}
+	manifest.Processes = mergeProcesses(processes, manifest.Processes)
+	return manifest, nil
+}
+
+func applyManifest(app App, manifest *Manifest) App {
+	if manifest == nil {
+		return app
+	}
+
+	overrides := app.Processes
+	if app.Command != "" && overrides[defaultProcessType].Command == "" {
+		overrides = mergeProcesses(overrides, map[string]ProcessType{
+			defaultProcessType: {Command: app.Command},
+		})
+	}
+	app.Processes = mergeProcesses(manifest.Processes, overrides)
+
+	if app.Command == "" {
+		app.Command = manifest.Command
+	}
+
+	if app.HealthCheck == nil {
+		app.HealthCheck = manifest.HealthCheck
+	}
+
+	if len(manifest.Jobs) > 0 {
+		jobs := append([]Job(nil), app.Jobs...)
+		for _, job := range manifest.Jobs {
+			if !slices.ContainsFunc(app.Jobs, func(j Job) bool { return j.Name == job.Name }) {
+				jobs = append(jobs, job)
+			}
+		}
+		app.Jobs = jobs
+	}
+
+	if len(manifest.Env) > 0 {
+		env := make(map[string]string, len(manifest.Env)+len(app.Env))
+		for key, value := range manifest.Env {
+			env[key] = value
+		}
+		for key, value := range app.Env {
+			env[key] = value
+		}
+		app.Env = env
+	}
+
+	if manifest.Hooks != nil {
+		hooks := *manifest.Hooks
+		if app.Hooks != nil {
+			hooks.PreStart = firstNonEmpty(app.Hooks.PreStart, hooks.PreStart)
+			hooks.PostStart = firstNonEmpty(app.Hooks.PostStart, hooks.PostStart)
+			hooks.PreStop = firstNonEmpty(app.Hooks.PreStop, hooks.PreStop)
+			hooks.OnFailure = firstNonEmpty(app.Hooks.OnFailure, hooks.OnFailure)
+			hooks.Timeout = firstNonEmpty(app.Hooks.Timeout, hooks.Timeout)
+		}
+		app.Hooks = &hooks
+	}
+
+	return app
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func checkZenVersion(required, current string) error {
+	required = strings.TrimSpace(required)
+	if required == "" || current == "dev" {
+		return nil
+	}
+
+	want, ok := parseSemver(strings.TrimSpace(strings.TrimPrefix(required, ">=")))
+	if !ok {
+		return fmt.Errorf("invalid zenVersion requirement %q", required)
+	}
+
+	have, ok := parseSemver(current)
+	if !ok {
+		return nil
+	}
+
+	if have.compare(want) < 0 {
+		return fmt.Errorf("release requires zen %s, running %s", required, current)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+type mockHealthChecker struct {
+	err    error
+	checks []HealthCheck
+}
+
+func (m *mockHealthChecker) Check(check HealthCheck, workDir string, env []string) error {
+	m.checks = append(m.checks, check)
+	return m.err
+}
+
+func TestApplyManifestServerConfigWins(t *testing.T) {
+	app := App{
+		Key:     "org/app",
+		Command: "./server --port 9000",
+		Env:     map[string]string{"PORT": "9000"},
+		Hooks:   &Hooks{PreStart: "custom-migrate"},
+	}
+	manifest := &Manifest{
+		Command:     "./server",
+		Env:         map[string]string{"PORT": "8080", "LOG_LEVEL": "info"},
+		Hooks:       &Hooks{PreStart: "migrate", PostStart: "warmup"},
+		HealthCheck: &HealthCheck{HTTP: "http://localhost:8080/health"},
+	}
+
+	merged := applyManifest(app, manifest)
+
+	if merged.Command != "./server --port 9000" {
+		t.Errorf("Expected server command to win, got '%s'", merged.Command)
+	}
+	if merged.Env["PORT"] != "9000" || merged.Env["LOG_LEVEL"] != "info" {
+		t.Errorf("Expected merged env, got %v", merged.Env)
+	}
+	if merged.Hooks.PreStart != "custom-migrate" || merged.Hooks.PostStart != "warmup" {
+		t.Errorf("Expected merged hooks, got %+v", merged.Hooks)
+	}
+	if merged.HealthCheck == nil || merged.HealthCheck.HTTP != "http://localhost:8080/health" {
+		t.Errorf("Expected manifest health check, got %+v", merged.HealthCheck)
+	}
+	if app.Env["LOG_LEVEL"] != "" {
+		t.Error("Expected original app env to be left untouched")
+	}
+}
+
+func TestCheckZenVersion(t *testing.T) {
+	tests := []struct {
+		required string
+		current  string
+		wantErr  bool
+	}{
+		{"", "v1.0.0", false},
+		{">=1.2.0", "dev", false},
+		{">=1.2.0", "v1.2.0", false},
+		{">=1.2.0", "v1.3.1", false},
+		{">=1.2.0", "v1.1.9", true},
+		{"2.0.0", "v1.9.0", true},
+		{"latest", "v1.0.0", true},
+	}
+
+	for _, tt := range tests {
+		err := checkZenVersion(tt.required, tt.current)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkZenVersion(%q, %q) error = %v, wantErr %v", tt.required, tt.current, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSwitchToUsesManifestCommand(t *testing.T) {
+	hooks := &mockHookRunner{}
+	updater, processManager := newHookTestUpdater(hooks)
+	updater.fs.(*mockFileSystemUpdater).files["/opt/zen/apps/org-app-1.1.0/zen.yaml"] = []byte(`
+command: ./bin/server
+env:
+  LOG_LEVEL: debug
+hooks:
+  preStart: ./bin/migrate
+`)
+
+	if err := updater.updateApp(App{Provider: "github", Key: "org/app"}, "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	last := processManager.started[len(processManager.started)-1]
+	if len(last.processes) != 1 || last.processes[0].Command != "./bin/server" || last.version != "1.1.0" {
+		t.Errorf("Expected ./bin/server 1.1.0 started, got %+v", last)
+	}
+
+	if len(hooks.calls) != 1 || hooks.calls[0].command != "./bin/migrate" {
+		t.Fatalf("Expected manifest pre-start hook, got %+v", hooks.calls)
+	}
+	if !slices.Contains(hooks.calls[0].env, "LOG_LEVEL=debug") {
+		t.Errorf("Expected manifest env in hook, got %v", hooks.calls[0].env)
+	}
+}
+
+func TestSwitchToAbortsOnInvalidManifest(t *testing.T) {
+	updater, processManager := newHookTestUpdater(&mockHookRunner{})
+	updater.fs.(*mockFileSystemUpdater).files["/opt/zen/apps/org-app-1.1.0/zen.json"] = []byte(`{"command": `)
+
+	app := App{Provider: "github", Key: "org/app", Command: "./run"}
+	if err := updater.updateApp(app, "", false); err == nil {
+		t.Fatal("Expected error for invalid manifest, got nil")
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process.Version != "1.0.0" {
+		t.Errorf("Expected 1.0.0 still running, got %s", process.Version)
+	}
+
+	deployments, _ := updater.Deployments("org/app")
+	if len(deployments) != 1 || deployments[0].Status != DeploymentAborted {
+		t.Errorf("Expected one aborted deployment, got %+v", deployments)
+	}
+}
+
+func TestFailedHealthCheckRollsBack(t *testing.T) {
+	updater, processManager := newHookTestUpdater(&mockHookRunner{})
+	checker := &mockHealthChecker{err: errors.New("connection refused")}
+	updater.healthChecker = checker
+
+	app := App{
+		Provider:    "github",
+		Key:         "org/app",
+		Command:     "./run",
+		HealthCheck: &HealthCheck{HTTP: "http://localhost:8080/health"},
+	}
+	if err := updater.updateApp(app, "", false); err == nil {
+		t.Fatal("Expected health check error, got nil")
+	}
+
+	if len(checker.checks) != 1 {
+		t.Errorf("Expected 1 health check, got %d", len(checker.checks))
+	}
+
+	if process, _ := processManager.GetProcess("org/app"); process == nil || process.Version != "1.0.0" {
+		t.Errorf("Expected rollback to 1.0.0, got %+v", process)
+	}
+
+	deployments, _ := updater.Deployments("org/app")
+	if len(deployments) != 1 || deployments[0].Status != DeploymentRolledBack {
+		t.Errorf("Expected one rolled-back deployment, got %+v", deployments)
+	}
+}
+
+func TestAppForReleaseCachesManifestPerInstallPath(t *testing.T) {
+	updater, _ := newHookTestUpdater(&mockHookRunner{})
+	fs := updater.fs.(*mockFileSystemUpdater)
+	fs.files["/opt/zen/apps/org-app-1.0.0/zen.yaml"] = []byte("command: ./first\n")
+
+	first, err := updater.appForRelease(App{Key: "org/app"}, "/opt/zen/apps/org-app-1.0.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	fs.files["/opt/zen/apps/org-app-1.0.0/zen.yaml"] = []byte("command: ./second\n")
+	second, _ := updater.appForRelease(App{Key: "org/app"}, "/opt/zen/apps/org-app-1.0.0")
+	if first.Command != "./first" || second.Command != "./first" {
+		t.Errorf("Expected cached manifest command ./first, got %s and %s", first.Command, second.Command)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	metricCounter   = "counter"
+	metricGauge     = "gauge"
+	metricHistogram = "histogram"
+)
+
+var (
+	latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	deployBuckets  = []float64{1, 5, 10, 30, 60, 120, 300, 600}
+)
+
+var (
+	metrics = newMetricsRegistry()
+
+	zenStartTime = time.Now()
+
+	updateChecksTotal        = metrics.counter("zen_update_checks_total", "Update checks performed per app.", "app")
+	updateCheckFailuresTotal = metrics.counter("zen_update_check_failures_total", "Update checks that failed per app.", "app")
+	githubRequestDuration    = metrics.histogram("zen_github_api_request_duration_seconds", "Latency of GitHub API requests.", latencyBuckets, "method", "code")
+	githubRateLimitRemaining = metrics.gauge("zen_github_api_rate_limit_remaining", "Requests left in the current GitHub rate-limit window.", "resource")
+	githubRateLimitLimit     = metrics.gauge("zen_github_api_rate_limit_limit", "Size of the GitHub rate-limit window.", "resource")
+	githubRateLimitReset     = metrics.gauge("zen_github_api_rate_limit_reset_timestamp_seconds", "Time the GitHub rate-limit window resets.", "resource")
+	deployDuration           = metrics.histogram("zen_deploy_duration_seconds", "Duration of deployments by outcome.", deployBuckets, "app", "status")
+	processRestartsTotal     = metrics.counter("zen_process_restarts_total", "Automatic restarts of app processes.", "app", "type")
+	healthChecksTotal        = metrics.counter("zen_health_checks_total", "Health check results by check kind.", "app", "check", "result")
+	alertsFiredTotal         = metrics.counter("zen_alerts_fired_total", "Alerts that started firing by rule.", "rule", "type")
+	eventsPublishedTotal     = metrics.counter("zen_events_published_total", "Deployment and process events published by type.", "type")
+	webhookDeliveriesTotal   = metrics.counter("zen_event_webhook_deliveries_total", "Outgoing event webhook deliveries by result.", "webhook", "result")
+	alertNotificationsTotal  = metrics.counter("zen_alert_notifications_total", "Alert notifications sent by notifier and result.", "notifier", "result")
+
+	zenBuildInfo      = metrics.gauge("zen_build_info", "Zen build information.", "version")
+	zenStartTimestamp = metrics.gauge("zen_start_time_seconds", "Time Zen started.")
+	zenUptime         = metrics.gauge("zen_uptime_seconds", "Seconds since Zen started.")
+	zenGoroutines     = metrics.gauge("go_goroutines", "Number of goroutines in the Zen process.")
+	zenCPUSeconds     = metrics.counter("process_cpu_seconds_total", "CPU time consumed by the Zen process.")
+	zenRSSBytes       = metrics.gauge("process_resident_memory_bytes", "Resident memory of the Zen process.")
+	zenOpenFDs        = metrics.gauge("process_open_fds", "Open file descriptors of the Zen process.")
+
+	appProcessUp     = metrics.gauge("zen_app_process_up", "Whether an app process is running.", "app", "type", "replica", "version")
+	appProcessUptime = metrics.gauge("zen_app_process_uptime_seconds", "Seconds since an app process was last started.", "app", "type", "replica")
+	appCPUSeconds    = metrics.counter("zen_app_cpu_seconds_total", "CPU time consumed by an app process group.", "app", "type", "replica")
+	appRSSBytes      = metrics.gauge("zen_app_memory_rss_bytes", "Resident memory of an app process group.", "app", "type", "replica")
+	appOpenFDs       = metrics.gauge("zen_app_open_fds", "Open file descriptors of an app process group.", "app", "type", "replica")
+)
+
+type metricSeries struct {
+	labels  []string
+	value   float64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+type metricVec struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64
+	mu         sync.Mutex
+	series     map[string]*metricSeries
+}
+
+func (v *metricVec) get(labels []string) *metricSeries {
+	if len(labels) != len(v.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d labels, got %d", v.name, len(v.labelNames), len(labels)))
+	}
+
+	key := strings.Join(labels, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &metricSeries{labels: append([]string{}, labels...)}
+		if v.kind == metricHistogram {
+			s.buckets = make([]uint64, len(v.buckets))
+		}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *metricVec) Inc(labels ...string) {
+	v.Add(1, labels...)
+}
+
+func (v *metricVec) Add(delta float64, labels ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.get(labels).value += delta
+}
+
+func (v *metricVec) Set(value float64, labels ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.get(labels).value = value
+}
+
+func (v *metricVec) Observe(value float64, labels ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s := v.get(labels)
+	for i, bound := range v.buckets {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+func (v *metricVec) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.series = make(map[string]*metricSeries)
+}
+
+func (v *metricVec) Value(labels ...string) float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if s, ok := v.series[strings.Join(labels, "\xff")]; ok {
+		if v.kind == metricHistogram {
+			return float64(s.count)
+		}
+		return s.value
+	}
+	return 0
+}
+
+func (v *metricVec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.series) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.kind)
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	leNames := append(slices.Clone(v.labelNames), "le")
+	for _, key := range keys {
+		s := v.series[key]
+		if v.kind != metricHistogram {
+			fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labelNames, s.labels), formatMetricValue(s.value))
+			continue
+		}
+
+		for i, bound := range v.buckets {
+			labels := formatLabels(leNames, append(slices.Clone(s.labels), formatMetricValue(bound)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, labels, s.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, formatLabels(leNames, append(slices.Clone(s.labels), "+Inf")), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, formatLabels(v.labelNames, s.labels), formatMetricValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, formatLabels(v.labelNames, s.labels), s.count)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escape.Replace(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+type metricsRegistry struct {
+	mu         sync.Mutex
+	scrapeMu   sync.Mutex
+	vecs       []*metricVec
+	collectors []func()
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{}
+}
+
+func (r *metricsRegistry) register(name, help, kind string, buckets []float64, labelNames []string) *metricVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v := &metricVec{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*metricSeries),
+	}
+	r.vecs = append(r.vecs, v)
+	return v
+}
+
+func (r *metricsRegistry) counter(name, help string, labelNames ...string) *metricVec {
+	return r.register(name, help, metricCounter, nil, labelNames)
+}
+
+func (r *metricsRegistry) gauge(name, help string, labelNames ...string) *metricVec {
+	return r.register(name, help, metricGauge, nil, labelNames)
+}
+
+func (r *metricsRegistry) histogram(name, help string, buckets []float64, labelNames ...string) *metricVec {
+	return r.register(name, help, metricHistogram, buckets, labelNames)
+}
+
+func (r *metricsRegistry) collect(collector func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collector)
+}
+
+func (r *metricsRegistry) Write(w io.Writer) {
+	r.scrapeMu.Lock()
+	defer r.scrapeMu.Unlock()
+
+	r.mu.Lock()
+	collectors := append([]func(){}, r.collectors...)
+	vecs := append([]*metricVec{}, r.vecs...)
+	r.mu.Unlock()
+
+	for _, collector := range collectors {
+		collector()
+	}
+	for _, v := range vecs {
+		v.write(w)
+	}
+}
+
+func recordHealthCheck(appKey, check string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	healthChecksTotal.Inc(appKey, check, result)
+}
+
+type githubMetricsTransport struct {
+	next http.RoundTripper
+	host string
+}
+
+func newGitHubMetricsTransport(next http.RoundTripper) *githubMetricsTransport {
+	host := strings.TrimPrefix(strings.TrimPrefix(githubAPIBaseURL, "https://"), "http://")
+	return &githubMetricsTransport{next: next, host: host}
+}
+
+func (t *githubMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+		recordGitHubRateLimit(resp.Header)
+	}
+	githubRequestDuration.Observe(time.Since(start).Seconds(), req.Method, code)
+	return resp, err
+}
+
+func recordGitHubRateLimit(header http.Header) {
+	remaining, err := strconv.ParseFloat(header.Get("X-RateLimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+	githubRateLimitRemaining.Set(remaining, resource)
+	if limit, err := strconv.ParseFloat(header.Get("X-RateLimit-Limit"), 64); err == nil {
+		githubRateLimitLimit.Set(limit, resource)
+	}
+	if reset, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset"), 64); err == nil {
+		githubRateLimitReset.Set(reset, resource)
+	}
+}
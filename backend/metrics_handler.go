@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var metricsToken string
+
+func metricsAuthorized(c *fiber.Ctx) bool {
+	if metricsToken != "" {
+		expected := "Bearer " + metricsToken
+		if subtle.ConstantTimeCompare([]byte(c.Get("Authorization")), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	return isAuthenticated(c)
+}
+
+func handleMetrics(c *fiber.Ctx) error {
+	if !metricsAuthorized(c) {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	c.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w := bufio.NewWriter(c)
+	metrics.Write(w)
+	return w.Flush()
+}
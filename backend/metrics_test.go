@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMetricsRegistryWritesExpositionFormat(t *testing.T) {
+	registry := newMetricsRegistry()
+	checks := registry.counter("test_checks_total", "Checks per app.", "app")
+	latency := registry.histogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "code")
+	registry.gauge("test_unused", "Never set.")
+
+	collected := 0
+	registry.collect(func() { collected++ })
+
+	checks.Inc("org/app")
+	checks.Add(2, "org/app")
+	checks.Inc(`we"ird\app`)
+	latency.Observe(0.05, "200")
+	latency.Observe(0.5, "200")
+	latency.Observe(3, "200")
+
+	var buf bytes.Buffer
+	registry.Write(&buf)
+
+	expected := `# HELP test_checks_total Checks per app.
+# TYPE test_checks_total counter
+test_checks_total{app="org/app"} 3
+test_checks_total{app="we\"ird\\app"} 1
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{code="200",le="0.1"} 1
+test_latency_seconds_bucket{code="200",le="1"} 2
+test_latency_seconds_bucket{code="200",le="+Inf"} 3
+test_latency_seconds_sum{code="200"} 3.55
+test_latency_seconds_count{code="200"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	if collected != 1 {
+		t.Errorf("Expected collectors to run once per scrape, got %d", collected)
+	}
+}
+
+func TestGitHubMetricsTransportRecordsLatencyAndRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4321")
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", "1717211520")
+		w.Header().Set("X-RateLimit-Resource", "metrics-test")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	client := &http.Client{Transport: &githubMetricsTransport{next: http.DefaultTransport, host: u.Host}}
+
+	before := githubRequestDuration.Value("GET", "200")
+	resp, err := client.Get(server.URL + "/repos/org/app/releases/latest")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if got := githubRequestDuration.Value("GET", "200"); got != before+1 {
+		t.Errorf("Expected one more observed request, got %v -> %v", before, got)
+	}
+	if got := githubRateLimitRemaining.Value("metrics-test"); got != 4321 {
+		t.Errorf("Expected remaining 4321, got %v", got)
+	}
+	if got := githubRateLimitLimit.Value("metrics-test"); got != 5000 {
+		t.Errorf("Expected limit 5000, got %v", got)
+	}
+	if got := githubRateLimitReset.Value("metrics-test"); got != 1717211520 {
+		t.Errorf("Expected reset timestamp, got %v", got)
+	}
+}
+
+func TestUpdateAppCountsChecksAndFailures(t *testing.T) {
+	au := &AppUpdater{providers: map[string]ReleaseProvider{}}
+	app := App{Key: "metrics/app", Provider: "unknown"}
+
+	if err := au.updateApp(app, "", false); err == nil {
+		t.Fatal("Expected error for unknown provider")
+	}
+
+	if got := updateChecksTotal.Value(app.Key); got != 1 {
+		t.Errorf("Expected 1 check, got %v", got)
+	}
+	if got := updateCheckFailuresTotal.Value(app.Key); got != 1 {
+		t.Errorf("Expected 1 failure, got %v", got)
+	}
+}
+
+func TestProcessGroupStats(t *testing.T) {
+	root := t.TempDir()
+	writeProc := func(pid, stat string, fds int) {
+		dir := filepath.Join(root, pid)
+		os.MkdirAll(filepath.Join(dir, "fd"), 0755)
+		os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644)
+		for i := 0; i < fds; i++ {
+			os.WriteFile(filepath.Join(dir, "fd", string(rune('0'+i))), nil, 0644)
+		}
+	}
+
+	writeProc("100", "100 (sh) S 1 100 100 0 -1 4194304 0 0 0 0 150 50 0 0 20 0 1 0 1000 1000 10 18446744073709551615", 3)
+	writeProc("101", "101 (my app) R 100 100 100 0 -1 4194304 0 0 0 0 100 100 0 0 20 0 4 0 1001 5000 20 18446744073709551615", 5)
+	writeProc("200", "200 (other) S 1 200 200 0 -1 4194304 0 0 0 0 999 999 0 0 20 0 1 0 900 1000 99 18446744073709551615", 1)
+	writeProc("self", "200 (other) S 1 200 200 0 -1 4194304 0 0 0 0 999 999 0 0 20 0 1 0 900 1000 99 18446744073709551615", 1)
+
+	groups, err := processGroupStats(root, []int{100, 300})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	page := int64(os.Getpagesize())
+	stats := groups[100]
+	if stats.Processes != 2 || stats.CPUSeconds != 4 || stats.RSSBytes != 30*page || stats.OpenFDs != 8 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	if _, ok := groups[300]; ok {
+		t.Error("Expected no stats for unknown process group")
+	}
+	if _, ok := groups[200]; ok {
+		t.Error("Expected unrequested process groups to be skipped")
+	}
+
+	self, err := selfProcessStats(root)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if self.Processes != 1 || self.CPUSeconds != 19.98 || self.OpenFDs != 1 {
+		t.Errorf("Unexpected self stats %+v", self)
+	}
+}
+
+func TestMetricsEndpointIncludesZenMetrics(t *testing.T) {
+	collectZenMetrics()
+
+	var buf bytes.Buffer
+	metrics.Write(&buf)
+
+	for _, name := range []string{"zen_build_info{version=", "zen_uptime_seconds ", "go_goroutines "} {
+		if !strings.Contains(buf.String(), name) {
+			t.Errorf("Expected %s in output", name)
+		}
+	}
+}
+
+func TestMetricsEndpointRequiresAuth(t *testing.T) {
+	originalToken, originalSecret := metricsToken, jwtSecret
+	metricsToken, jwtSecret = "scrape-token", []byte("test-secret")
+	defer func() { metricsToken, jwtSecret = originalToken, originalSecret }()
+
+	app := fiber.New()
+	app.Get("/metrics", handleMetrics)
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if resp.StatusCode != 401 {
+		t.Errorf("Expected 401 without credentials, got %d", resp.StatusCode)
+	}
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape-token")
+	if resp, _ := app.Test(req); resp.StatusCode != 200 {
+		t.Errorf("Expected 200 with metrics token, got %d", resp.StatusCode)
+	}
+
+	token, _ := generateJWT("admin")
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.AddCookie(&http.Cookie{Name: "auth_token", Value: token})
+	if resp, _ := app.Test(req); resp.StatusCode != 200 {
+		t.Errorf("Expected 200 with session cookie, got %d", resp.StatusCode)
+	}
+
+	metricsToken = ""
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	if resp, _ := app.Test(req); resp.StatusCode != 401 {
+		t.Errorf("Expected 401 when no metrics token is configured, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	NotifierWebhook = "webhook"
+	NotifierSlack   = "slack"
+	NotifierDiscord = "discord"
+	NotifierNtfy    = "ntfy"
+	NotifierSMTP    = "smtp"
+
+	notifierTimeout = 10 * time.Second
+)
+
+type Notifier struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	URL      string            `json:"url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Token    string            `json:"token,omitempty"`
+	Address  string            `json:"address,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	From     string            `json:"from,omitempty"`
+	To       []string          `json:"to,omitempty"`
+}
+
+type NotifierSender interface {
+	Send(alert Alert) error
+}
+
+func newNotifierSender(notifier Notifier) (NotifierSender, error) {
+	switch notifier.Type {
+	case NotifierWebhook, NotifierSlack, NotifierDiscord, NotifierNtfy:
+		u, err := url.Parse(notifier.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("%s notifier requires an http(s) url", notifier.Type)
+		}
+		return &httpNotifier{config: notifier, client: &http.Client{Timeout: notifierTimeout}}, nil
+	case NotifierSMTP:
+		if _, _, err := net.SplitHostPort(notifier.Address); err != nil {
+			return nil, fmt.Errorf("smtp notifier requires a host:port address")
+		}
+		if notifier.From == "" || len(notifier.To) == 0 {
+			return nil, fmt.Errorf("smtp notifier requires from and to addresses")
+		}
+		return &smtpNotifier{config: notifier}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", notifier.Type)
+	}
+}
+
+func alertTitle(alert Alert) string {
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(alert.Status), alert.Rule, alert.Subject)
+}
+
+func alertText(alert Alert) string {
+	text := alertTitle(alert) + "\n" + alert.Summary
+	if alert.Status == AlertResolved && alert.EndsAt != nil {
+		text += fmt.Sprintf("\nResolved after %s", alert.EndsAt.Sub(alert.StartsAt).Round(time.Second))
+	}
+	return text
+}
+
+type httpNotifier struct {
+	config Notifier
+	client HTTPClient
+}
+
+func (n *httpNotifier) request(alert Alert) (*http.Request, error) {
+	var body []byte
+	contentType := "application/json"
+
+	switch n.config.Type {
+	case NotifierSlack:
+		body, _ = json.Marshal(map[string]string{"text": alertText(alert)})
+	case NotifierDiscord:
+		body, _ = json.Marshal(map[string]string{"content": alertText(alert)})
+	case NotifierNtfy:
+		body = []byte(alert.Summary)
+		contentType = "text/plain"
+	default:
+		body, _ = json.Marshal(alert)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if n.config.Type == NotifierNtfy {
+		req.Header.Set("Title", alertTitle(alert))
+		req.Header.Set("Tags", ntfyTag(alert))
+		if alert.Status == AlertFiring && alert.Severity == AlertCritical {
+			req.Header.Set("Priority", "urgent")
+		}
+	}
+	if n.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.Token)
+	}
+	for key, value := range n.config.Headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+func ntfyTag(alert Alert) string {
+	switch {
+	case alert.Status == AlertResolved:
+		return "white_check_mark"
+	case alert.Severity == AlertCritical:
+		return "rotating_light"
+	default:
+		return "warning"
+	}
+}
+
+func (n *httpNotifier) Send(alert Alert) error {
+	req, err := n.request(alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s notification failed with status %d", n.config.Type, resp.StatusCode)
+	}
+	return nil
+}
+
+type smtpNotifier struct {
+	config Notifier
+}
+
+func (n *smtpNotifier) message(alert Alert) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(n.config.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", alertTitle(alert))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(alertText(alert), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+func (n *smtpNotifier) Send(alert Alert) error {
+	host, _, _ := net.SplitHostPort(n.config.Address)
+	conn, err := net.DialTimeout("tcp", n.config.Address, notifierTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(notifierTimeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.config.From); err != nil {
+		return err
+	}
+	for _, to := range n.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.message(alert)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type capturedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newNotifierServer(t *testing.T, status int) (*httptest.Server, chan capturedRequest) {
+	t.Helper()
+
+	requests := make(chan capturedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- capturedRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func testAlert() Alert {
+	return Alert{
+		Rule:     "down",
+		Type:     AlertAppDown,
+		Severity: AlertCritical,
+		Status:   AlertFiring,
+		Subject:  "org/app",
+		AppKey:   "org/app",
+		Summary:  "org/app has had no running processes for 1m0s",
+		StartsAt: time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestHTTPNotifierPayloads(t *testing.T) {
+	server, requests := newNotifierServer(t, http.StatusOK)
+
+	for _, notifierType := range []string{NotifierWebhook, NotifierSlack, NotifierDiscord, NotifierNtfy} {
+		sender, err := newNotifierSender(Notifier{Name: notifierType, Type: notifierType, URL: server.URL, Token: "secret"})
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", notifierType, err)
+		}
+		if err := sender.Send(testAlert()); err != nil {
+			t.Fatalf("Expected no error sending %s, got %v", notifierType, err)
+		}
+
+		req := <-requests
+		if req.header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token for %s, got %q", notifierType, req.header.Get("Authorization"))
+		}
+
+		switch notifierType {
+		case NotifierWebhook:
+			var alert Alert
+			if err := json.Unmarshal(req.body, &alert); err != nil || alert.Rule != "down" || alert.AppKey != "org/app" {
+				t.Errorf("Expected alert JSON, got %s", req.body)
+			}
+		case NotifierSlack:
+			var payload map[string]string
+			json.Unmarshal(req.body, &payload)
+			if !strings.HasPrefix(payload["text"], "[FIRING] down: org/app") {
+				t.Errorf("Expected slack text, got %s", req.body)
+			}
+		case NotifierDiscord:
+			var payload map[string]string
+			json.Unmarshal(req.body, &payload)
+			if !strings.Contains(payload["content"], "no running processes") {
+				t.Errorf("Expected discord content, got %s", req.body)
+			}
+		case NotifierNtfy:
+			if string(req.body) != testAlert().Summary || req.header.Get("Title") != "[FIRING] down: org/app" {
+				t.Errorf("Unexpected ntfy request %v %s", req.header, req.body)
+			}
+			if req.header.Get("Priority") != "urgent" || req.header.Get("Tags") != "rotating_light" {
+				t.Errorf("Expected urgent priority for critical alert, got %v", req.header)
+			}
+		}
+	}
+}
+
+func TestHTTPNotifierReportsFailureStatus(t *testing.T) {
+	server, _ := newNotifierServer(t, http.StatusBadGateway)
+
+	sender, _ := newNotifierSender(Notifier{Name: "hook", Type: NotifierWebhook, URL: server.URL})
+	if err := sender.Send(testAlert()); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
+
+func TestNewNotifierSenderValidates(t *testing.T) {
+	invalid := []Notifier{
+		{Name: "a", Type: NotifierWebhook, URL: "ftp://example.com"},
+		{Name: "b", Type: NotifierSMTP, Address: "mail.example.com"},
+		{Name: "c", Type: NotifierSMTP, Address: "mail.example.com:25"},
+		{Name: "d", Type: "pager"},
+	}
+	for _, notifier := range invalid {
+		if _, err := newNotifierSender(notifier); err == nil {
+			t.Errorf("Expected error for %+v", notifier)
+		}
+	}
+}
+
+func startFakeSMTPServer(t *testing.T) (string, chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 localhost ESMTP")
+
+		var envelope []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"):
+				envelope = append(envelope, strings.TrimSpace(line))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				messages <- strings.Join(envelope, "\n") + "\n" + data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPNotifierSendsMail(t *testing.T) {
+	address, messages := startFakeSMTPServer(t)
+
+	sender, err := newNotifierSender(Notifier{
+		Name:    "mail",
+		Type:    NotifierSMTP,
+		Address: address,
+		From:    "zen@example.com",
+		To:      []string{"ops@example.com", "dev@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := sender.Send(testAlert()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	select {
+	case message := <-messages:
+		for _, expected := range []string{"MAIL FROM:<zen@example.com>", "RCPT TO:<dev@example.com>", "Subject: [FIRING] down: org/app", "no running processes"} {
+			if !strings.Contains(message, expected) {
+				t.Errorf("Expected %q in message:\n%s", expected, message)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for mail")
+	}
+}
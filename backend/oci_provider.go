@@ -0,0 +1,427 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ociManifestMediaType       = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType    = "application/vnd.docker.distribution.manifest.v2+json"
+	ociTitleAnnotation         = "org.opencontainers.image.title"
+	ociTarGzipLayerMediaType   = "application/vnd.oci.image.layer.v1.tar+gzip"
+	ociZipLayerMediaTypeSuffix = "zip"
+)
+
+type ociReference struct {
+	baseURL    string
+	repository string
+	reference  string
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+type ociProvider struct {
+	client HTTPClient
+}
+
+func parseOCIReference(key string) (*ociReference, error) {
+	scheme := "https"
+	if i := strings.Index(key, "://"); i >= 0 {
+		scheme = key[:i]
+		key = key[i+3:]
+	}
+
+	slash := strings.Index(key, "/")
+	if slash <= 0 || slash == len(key)-1 {
+		return nil, fmt.Errorf("invalid OCI reference %q, expected registry/repository[:tag|@digest]", key)
+	}
+
+	host := key[:slash]
+	repository := key[slash+1:]
+	reference := ""
+
+	if at := strings.Index(repository, "@"); at >= 0 {
+		reference = repository[at+1:]
+		repository = repository[:at]
+	} else if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		reference = repository[colon+1:]
+		repository = repository[:colon]
+	}
+
+	if repository == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q, missing repository", key)
+	}
+
+	return &ociReference{
+		baseURL:    scheme + "://" + host,
+		repository: repository,
+		reference:  reference,
+	}, nil
+}
+
+func (p *ociProvider) GetLatestRelease(app App, token string) (*Release, error) {
+	ref, err := parseOCIReference(app.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := ref.reference
+	pinned := tag != ""
+	if !pinned {
+		tags, err := p.listTags(ref, token)
+		if err != nil {
+			return nil, err
+		}
+
+		latest, ok := latestSemverTag(tags)
+		if !ok {
+			return nil, fmt.Errorf("no semver tags found in %s", ref.repository)
+		}
+		tag = latest
+	}
+
+	manifest, digest, err := p.getManifest(ref, tag, token)
+	if err != nil {
+		return nil, err
+	}
+
+	layer, assetName, err := selectOCILayer(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	version := tag
+	if strings.HasPrefix(tag, "sha256:") {
+		version = shortDigest(tag)
+	} else if _, ok := parseSemver(tag); !ok {
+		version = tag + "-" + shortDigest(digest)
+	}
+
+	return &Release{
+		Version:   version,
+		AssetName: assetName,
+		AssetURL:  fmt.Sprintf("%s/v2/%s/blobs/%s", ref.baseURL, ref.repository, layer.Digest),
+	}, nil
+}
+
+func (p *ociProvider) DownloadRelease(app App, release *Release, token string) (io.ReadCloser, error) {
+	ref, err := parseOCIReference(app.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", release.AssetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.do(req, ref, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("blob download failed with status %d", resp.StatusCode)
+	}
+
+	expected := release.AssetURL[strings.LastIndex(release.AssetURL, "/")+1:]
+	if !strings.HasPrefix(expected, "sha256:") {
+		return resp.Body, nil
+	}
+
+	return &digestVerifyingReader{
+		body:     resp.Body,
+		hash:     sha256.New(),
+		expected: strings.TrimPrefix(expected, "sha256:"),
+	}, nil
+}
+
+func (p *ociProvider) ping(host, token string) error {
+	baseURL := host
+	if !strings.Contains(host, "://") {
+		baseURL = "https://" + host
+	}
+	ref := &ociReference{baseURL: strings.TrimSuffix(baseURL, "/")}
+
+	req, err := http.NewRequest("GET", ref.baseURL+"/v2/", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.do(req, ref, token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry rejected credentials with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *ociProvider) listTags(ref *ociReference, token string) ([]string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v2/%s/tags/list", ref.baseURL, ref.repository), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.do(req, ref, token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned status %d listing tags", resp.StatusCode)
+	}
+
+	var tagList struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tagList); err != nil {
+		return nil, err
+	}
+
+	return tagList.Tags, nil
+}
+
+func (p *ociProvider) getManifest(ref *ociReference, reference, token string) (*ociManifest, string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v2/%s/manifests/%s", ref.baseURL, ref.repository, reference), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", ociManifestMediaType+", "+dockerManifestMediaType)
+
+	resp, err := p.do(req, ref, token)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("registry returned status %d fetching manifest %s", resp.StatusCode, reference)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, "", err
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		sum := sha256.Sum256(data)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return &manifest, digest, nil
+}
+
+func (p *ociProvider) do(req *http.Request, ref *ociReference, token string) (*http.Response, error) {
+	setRegistryCredentials(req, token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	scheme, params := parseAuthChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") || params["realm"] == "" {
+		return nil, fmt.Errorf("registry authentication failed for %s", ref.baseURL)
+	}
+
+	bearer, err := p.fetchBearerToken(params, ref, token)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Header.Set("Authorization", "Bearer "+bearer)
+
+	return p.client.Do(retry)
+}
+
+func (p *ociProvider) fetchBearerToken(params map[string]string, ref *ociReference, token string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", err
+	}
+
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" && ref.repository != "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.repository)
+	}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username, password, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", err
+	}
+
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	if tokenResp.AccessToken != "" {
+		return tokenResp.AccessToken, nil
+	}
+	return "", fmt.Errorf("registry token endpoint returned no token")
+}
+
+func setRegistryCredentials(req *http.Request, token string) {
+	if token == "" {
+		return
+	}
+	if username, password, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(username, password)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+func parseAuthChallenge(header string) (string, map[string]string) {
+	params := make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[strings.TrimSpace(key)] = value[1:]
+				break
+			}
+			params[strings.TrimSpace(key)] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			v, remaining, _ := strings.Cut(value, ",")
+			params[strings.TrimSpace(key)] = strings.TrimSpace(v)
+			rest = remaining
+		}
+	}
+
+	return scheme, params
+}
+
+func selectOCILayer(manifest *ociManifest) (*ociDescriptor, string, error) {
+	if len(manifest.Layers) == 0 {
+		return nil, "", fmt.Errorf("no layers found in manifest")
+	}
+
+	for i := range manifest.Layers {
+		title := manifest.Layers[i].Annotations[ociTitleAnnotation]
+		if title != "" && filepath.Base(title) != title {
+			return nil, "", fmt.Errorf("unsafe layer title %q", title)
+		}
+		if isSupportedArchive(title) {
+			return &manifest.Layers[i], title, nil
+		}
+	}
+
+	layer := &manifest.Layers[0]
+	switch {
+	case layer.MediaType == ociTarGzipLayerMediaType || strings.HasSuffix(layer.MediaType, "tar+gzip"):
+		return layer, "artifact.tar.gz", nil
+	case strings.HasSuffix(layer.MediaType, ociZipLayerMediaTypeSuffix):
+		return layer, "artifact.zip", nil
+	}
+
+	return nil, "", fmt.Errorf("no archive layer found in manifest")
+}
+
+func isSupportedArchive(name string) bool {
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".zip")
+}
+
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+type digestVerifyingReader struct {
+	body     io.ReadCloser
+	hash     hash.Hash
+	expected string
+}
+
+func (r *digestVerifyingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if actual := hex.EncodeToString(r.hash.Sum(nil)); actual != r.expected {
+			return n, fmt.Errorf("digest mismatch: expected sha256:%s, got sha256:%s", r.expected, actual)
+		}
+	}
+	return n, err
+}
+
+func (r *digestVerifyingReader) Close() error {
+	return r.body.Close()
+}
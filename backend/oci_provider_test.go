@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeRegistry struct {
+	server      *httptest.Server
+	repository  string
+	tags        map[string][]byte
+	blobs       map[string][]byte
+	requireAuth bool
+}
+
+func newFakeRegistry(t *testing.T, repository string) *fakeRegistry {
+	r := &fakeRegistry{
+		repository: repository,
+		tags:       make(map[string][]byte),
+		blobs:      make(map[string][]byte),
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(r.handle))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *fakeRegistry) push(tag, title string, content []byte) string {
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	r.blobs[digest] = content
+
+	manifest := ociManifest{
+		MediaType: ociManifestMediaType,
+		Layers: []ociDescriptor{{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar",
+			Digest:      digest,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ociTitleAnnotation: title},
+		}},
+	}
+	data, _ := json.Marshal(manifest)
+	r.tags[tag] = data
+	return digest
+}
+
+func (r *fakeRegistry) handle(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		json.NewEncoder(w).Encode(map[string]string{"token": "registry-token"})
+		return
+	}
+
+	if r.requireAuth && req.Header.Get("Authorization") != "Bearer registry-token" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	prefix := "/v2/" + r.repository + "/"
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		http.NotFound(w, req)
+		return
+	}
+	rest := strings.TrimPrefix(req.URL.Path, prefix)
+
+	switch {
+	case rest == "tags/list":
+		tags := make([]string, 0, len(r.tags))
+		for tag := range r.tags {
+			tags = append(tags, tag)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"name": r.repository, "tags": tags})
+	case strings.HasPrefix(rest, "manifests/"):
+		manifest, ok := r.tags[strings.TrimPrefix(rest, "manifests/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		w.Write(manifest)
+	case strings.HasPrefix(rest, "blobs/"):
+		blob, ok := r.blobs[strings.TrimPrefix(rest, "blobs/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(blob)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		input      string
+		baseURL    string
+		repository string
+		reference  string
+	}{
+		{"ghcr.io/org/app", "https://ghcr.io", "org/app", ""},
+		{"ghcr.io/org/app:1.2.0", "https://ghcr.io", "org/app", "1.2.0"},
+		{"localhost:5000/app@sha256:abc", "https://localhost:5000", "app", "sha256:abc"},
+		{"http://localhost:5000/org/app", "http://localhost:5000", "org/app", ""},
+	}
+
+	for _, tt := range tests {
+		ref, err := parseOCIReference(tt.input)
+		if err != nil {
+			t.Fatalf("parseOCIReference(%s) returned error: %v", tt.input, err)
+		}
+		if ref.baseURL != tt.baseURL || ref.repository != tt.repository || ref.reference != tt.reference {
+			t.Errorf("parseOCIReference(%s) = %+v", tt.input, ref)
+		}
+	}
+}
+
+func TestOCIProviderPicksNewestSemverTag(t *testing.T) {
+	registry := newFakeRegistry(t, "org/app")
+	registry.push("1.2.0", "app.tar.gz", []byte("old"))
+	registry.push("1.10.0", "app.tar.gz", []byte("new"))
+	registry.push("latest", "app.tar.gz", []byte("new"))
+	registry.push("2.0.0-rc.1", "app.tar.gz", []byte("rc"))
+
+	provider := &ociProvider{client: registry.server.Client()}
+	app := App{Provider: "oci", Key: registry.server.URL + "/org/app"}
+
+	release, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if release.Version != "2.0.0-rc.1" {
+		t.Errorf("Expected version '2.0.0-rc.1', got '%s'", release.Version)
+	}
+
+	if release.AssetName != "app.tar.gz" {
+		t.Errorf("Expected asset 'app.tar.gz', got '%s'", release.AssetName)
+	}
+}
+
+func TestOCIProviderDownloadsBlobWithBearerAuth(t *testing.T) {
+	registry := newFakeRegistry(t, "org/app")
+	registry.requireAuth = true
+	registry.push("1.0.0", "app.zip", []byte("zip-content"))
+
+	provider := &ociProvider{client: registry.server.Client()}
+	app := App{Provider: "oci", Key: registry.server.URL + "/org/app"}
+
+	release, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	body, err := provider.DownloadRelease(app, release, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Expected no error reading blob, got %v", err)
+	}
+
+	if string(data) != "zip-content" {
+		t.Errorf("Expected blob 'zip-content', got '%s'", data)
+	}
+}
+
+func TestOCIProviderRejectsDigestMismatch(t *testing.T) {
+	registry := newFakeRegistry(t, "org/app")
+	digest := registry.push("1.0.0", "app.tar.gz", []byte("original"))
+	registry.blobs[digest] = []byte("tampered")
+
+	provider := &ociProvider{client: registry.server.Client()}
+	app := App{Provider: "oci", Key: registry.server.URL + "/org/app"}
+
+	release, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	body, err := provider.DownloadRelease(app, release, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer body.Close()
+
+	if _, err := io.ReadAll(body); err == nil {
+		t.Error("Expected digest mismatch error, got nil")
+	}
+}
+
+func TestOCIProviderMutableTagIncludesDigest(t *testing.T) {
+	registry := newFakeRegistry(t, "org/app")
+	registry.push("stable", "app.tar.gz", []byte("content"))
+
+	provider := &ociProvider{client: registry.server.Client()}
+	app := App{Provider: "oci", Key: registry.server.URL + "/org/app:stable"}
+
+	release, err := provider.GetLatestRelease(app, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(release.Version, "stable-") || len(release.Version) != len("stable-")+12 {
+		t.Errorf("Expected version 'stable-<digest>', got '%s'", release.Version)
+	}
+}
+
+func TestOCIProviderRejectsUnsafeLayerTitle(t *testing.T) {
+	registry := newFakeRegistry(t, "org/app")
+	registry.push("1.0.0", "../../app.tar.gz", []byte("content"))
+
+	provider := &ociProvider{client: registry.server.Client()}
+	app := App{Provider: "oci", Key: registry.server.URL + "/org/app"}
+
+	if _, err := provider.GetLatestRelease(app, ""); err == nil || !strings.Contains(err.Error(), "unsafe layer title") {
+		t.Errorf("Expected unsafe layer title error, got %v", err)
+	}
+}
@@ -9,27 +9,37 @@ import (
 )
 
 type Params struct {
-	JWTSecret string `json:"jwtSecret"`
+	JWTSecret     string `json:"jwtSecret"`
+	EncryptionKey string `json:"encryptionKey"`
 }
 
 func loadOrCreateParams() (*Params, error) {
 	paramsFilePath := "/opt/zen/data/params.json"
 
+	params := &Params{}
 	if data, err := os.ReadFile(paramsFilePath); err == nil {
-		var params Params
-		if err := json.Unmarshal(data, &params); err == nil {
-			return &params, nil
+		var existing Params
+		if err := json.Unmarshal(data, &existing); err == nil {
+			if existing.EncryptionKey != "" {
+				return &existing, nil
+			}
+			params = &existing
 		}
 	}
 
-	secret := make([]byte, 32)
-	if _, err := rand.Read(secret); err != nil {
-		return nil, err
+	if params.JWTSecret == "" {
+		secret, err := randomBase64(32)
+		if err != nil {
+			return nil, err
+		}
+		params.JWTSecret = secret
 	}
 
-	params := &Params{
-		JWTSecret: base64.StdEncoding.EncodeToString(secret),
+	key, err := randomBase64(32)
+	if err != nil {
+		return nil, err
 	}
+	params.EncryptionKey = key
 
 	paramsDir := filepath.Dir(paramsFilePath)
 	if err := os.MkdirAll(paramsDir, 0755); err != nil {
@@ -47,3 +57,11 @@ func loadOrCreateParams() (*Params, error) {
 
 	return params, nil
 }
+
+func randomBase64(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
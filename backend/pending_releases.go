@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const PendingOutsideWindow = "outside-deploy-window"
+
+type PendingRelease struct {
+	AppKey     string     `json:"appKey"`
+	Version    string     `json:"version"`
+	Reason     string     `json:"reason"`
+	DetectedAt time.Time  `json:"detectedAt"`
+	ApplyAfter *time.Time `json:"applyAfter,omitempty"`
+}
+
+type pendingReleases struct {
+	fs       FileSystemOps
+	filePath string
+	mu       sync.Mutex
+	items    map[string]PendingRelease
+	loaded   bool
+}
+
+func newPendingReleases(fs FileSystemOps, filePath string) *pendingReleases {
+	return &pendingReleases{
+		fs:       fs,
+		filePath: filePath,
+		items:    make(map[string]PendingRelease),
+	}
+}
+
+func (p *pendingReleases) Set(release PendingRelease) PendingRelease {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.load()
+
+	existing, ok := p.items[release.AppKey]
+	if ok && existing.Version == release.Version {
+		release.DetectedAt = existing.DetectedAt
+	}
+	if !ok || !reflect.DeepEqual(existing, release) {
+		p.items[release.AppKey] = release
+		p.store()
+	}
+	return release
+}
+
+func (p *pendingReleases) Get(appKey string) (PendingRelease, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.load()
+
+	release, ok := p.items[appKey]
+	return release, ok
+}
+
+func (p *pendingReleases) Delete(appKey string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.load()
+
+	if _, ok := p.items[appKey]; ok {
+		delete(p.items, appKey)
+		p.store()
+	}
+}
+
+func (p *pendingReleases) List() []PendingRelease {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.load()
+
+	releases := make([]PendingRelease, 0, len(p.items))
+	for _, release := range p.items {
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].AppKey < releases[j].AppKey
+	})
+	return releases
+}
+
+func (p *pendingReleases) load() {
+	if p.loaded {
+		return
+	}
+	p.loaded = true
+
+	data, err := p.fs.ReadFile(p.filePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			subsystemLogger("approval").Error("Failed to load pending releases", "error", err)
+		}
+		return
+	}
+
+	if err := json.Unmarshal(data, &p.items); err != nil {
+		subsystemLogger("approval").Error("Failed to load pending releases", "error", err)
+	}
+}
+
+func (p *pendingReleases) store() {
+	if err := p.fs.MkdirAll(filepath.Dir(p.filePath), 0755); err != nil {
+		subsystemLogger("approval").Error("Failed to store pending releases", "error", err)
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(p.items, "", "  ")
+	if err != nil {
+		subsystemLogger("approval").Error("Failed to store pending releases", "error", err)
+		return
+	}
+
+	if err := p.fs.WriteFile(p.filePath, jsonData, 0600); err != nil {
+		subsystemLogger("approval").Error("Failed to store pending releases", "error", err)
+	}
+}
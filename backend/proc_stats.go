@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	procRoot       = "/proc"
+	procClockTicks = 100
+)
+
+type processStats struct {
+	Processes  int
+	CPUSeconds float64
+	RSSBytes   int64
+	Threads    int
+	OpenFDs    int
+	ReadBytes  int64
+	WriteBytes int64
+}
+
+type procStat struct {
+	pgrp    int
+	cpu     float64
+	rss     int64
+	threads int
+	valid   bool
+}
+
+func readProcStat(root, name string) procStat {
+	data, err := os.ReadFile(filepath.Join(root, name, "stat"))
+	if err != nil {
+		return procStat{}
+	}
+
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 22 {
+		return procStat{}
+	}
+
+	pgrp, _ := strconv.Atoi(fields[2])
+	utime, _ := strconv.ParseFloat(fields[11], 64)
+	stime, _ := strconv.ParseFloat(fields[12], 64)
+	threads, _ := strconv.Atoi(fields[17])
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+
+	return procStat{
+		pgrp:    pgrp,
+		cpu:     (utime + stime) / procClockTicks,
+		rss:     rss * int64(os.Getpagesize()),
+		threads: threads,
+		valid:   true,
+	}
+}
+
+func readProcIO(root, name string) (readBytes, writeBytes int64) {
+	data, err := os.ReadFile(filepath.Join(root, name, "io"))
+	if err != nil {
+		return 0, 0
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		switch key {
+		case "read_bytes":
+			readBytes = n
+		case "write_bytes":
+			writeBytes = n
+		}
+	}
+	return readBytes, writeBytes
+}
+
+func (stats *processStats) add(root, name string, stat procStat) {
+	stats.Processes++
+	stats.CPUSeconds += stat.cpu
+	stats.RSSBytes += stat.rss
+	stats.Threads += stat.threads
+	readBytes, writeBytes := readProcIO(root, name)
+	stats.ReadBytes += readBytes
+	stats.WriteBytes += writeBytes
+	if fds, err := os.ReadDir(filepath.Join(root, name, "fd")); err == nil {
+		stats.OpenFDs += len(fds)
+	}
+}
+
+func processGroupStats(root string, pgids []int) (map[int]processStats, error) {
+	groups := make(map[int]processStats, len(pgids))
+	if len(pgids) == 0 {
+		return groups, nil
+	}
+
+	wanted := make(map[int]bool, len(pgids))
+	for _, pgid := range pgids {
+		wanted[pgid] = true
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+
+		stat := readProcStat(root, entry.Name())
+		if !stat.valid || !wanted[stat.pgrp] {
+			continue
+		}
+
+		stats := groups[stat.pgrp]
+		stats.add(root, entry.Name(), stat)
+		groups[stat.pgrp] = stats
+	}
+	return groups, nil
+}
+
+func selfProcessStats(root string) (processStats, error) {
+	stat := readProcStat(root, "self")
+	if !stat.valid {
+		return processStats{}, fmt.Errorf("failed to read own process stats")
+	}
+
+	var stats processStats
+	stats.add(root, "self", stat)
+	return stats, nil
+}
+
+func runningPIDs(infos ...*ProcessInfo) []int {
+	var pids []int
+	for _, info := range infos {
+		for _, state := range info.Processes {
+			if state.Running {
+				pids = append(pids, state.PID)
+			}
+		}
+	}
+	return pids
+}
+
+func collectZenMetrics() {
+	zenBuildInfo.Set(1, buildVersion)
+	zenStartTimestamp.Set(float64(zenStartTime.Unix()))
+	zenUptime.Set(time.Since(zenStartTime).Seconds())
+	zenGoroutines.Set(float64(runtime.NumGoroutine()))
+
+	stats, err := selfProcessStats(procRoot)
+	if err != nil {
+		return
+	}
+	zenCPUSeconds.Set(stats.CPUSeconds)
+	zenRSSBytes.Set(float64(stats.RSSBytes))
+	zenOpenFDs.Set(float64(stats.OpenFDs))
+}
+
+func (au *AppUpdater) collectMetrics() {
+	appProcessUp.Reset()
+	appProcessUptime.Reset()
+	appCPUSeconds.Reset()
+	appRSSBytes.Reset()
+	appOpenFDs.Reset()
+
+	setupData, err := au.loadSetupData()
+	if err != nil {
+		return
+	}
+
+	var infos []*ProcessInfo
+	for _, app := range setupData.Apps {
+		if info, err := au.ProcessManager.GetProcess(app.Key); err == nil {
+			infos = append(infos, info)
+		}
+	}
+
+	groups, err := processGroupStats(procRoot, runningPIDs(infos...))
+	if err != nil {
+		return
+	}
+
+	for _, info := range infos {
+		for _, state := range info.Processes {
+			replica := strconv.Itoa(state.Replica)
+			if !state.Running {
+				appProcessUp.Set(0, info.AppKey, state.Type, replica, state.Version)
+				continue
+			}
+
+			appProcessUp.Set(1, info.AppKey, state.Type, replica, state.Version)
+			appProcessUptime.Set(time.Since(state.StartedAt).Seconds(), info.AppKey, state.Type, replica)
+
+			stats, ok := groups[state.PID]
+			if !ok {
+				continue
+			}
+			appCPUSeconds.Set(stats.CPUSeconds, info.AppKey, state.Type, replica)
+			appRSSBytes.Set(float64(stats.RSSBytes), info.AppKey, state.Type, replica)
+			appOpenFDs.Set(float64(stats.OpenFDs), info.AppKey, state.Type, replica)
+		}
+	}
+}
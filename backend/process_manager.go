@@ -2,120 +2,524 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"syscall"
+	"time"
 )
 
+const (
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+	RestartNever     = "never"
+
+	maxRestartDelay = 30 * time.Second
+	stopTimeout     = 10 * time.Second
+)
+
+type ProcessSpec struct {
+	Type       string
+	Command    string
+	Replicas   int
+	Restart    string
+	Port       int
+	Balance    string
+	HealthPath string
+	LogDir     string
+	LogPolicy  logPolicy
+}
+
+type ProcessState struct {
+	Type      string    `json:"type"`
+	Replica   int       `json:"replica"`
+	Version   string    `json:"version"`
+	PID       int       `json:"pid"`
+	Port      int       `json:"port,omitempty"`
+	Running   bool      `json:"running"`
+	Ready     bool      `json:"ready"`
+	Restarts  int       `json:"restarts"`
+	LogFile   string    `json:"logFile"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
 type ProcessInfo struct {
-	PID         int    `json:"pid"`
-	AppKey      string `json:"appKey"`
-	Version     string `json:"version"`
-	InstallPath string `json:"installPath"`
+	AppKey      string         `json:"appKey"`
+	Version     string         `json:"version"`
+	InstallPath string         `json:"installPath"`
+	Processes   []ProcessState `json:"processes"`
 }
 
 type ProcessManager interface {
-	Start(appKey, version, command, workDir string) error
+	Start(appKey, version, workDir string, processes []ProcessSpec, env []string) error
+	Upgrade(appKey, version, workDir string, processes []ProcessSpec, env []string, ready func(ProcessState) error) error
 	Stop(appKey string) error
 	StopAll()
 	IsRunning(appKey string) bool
 	GetProcess(appKey string) (*ProcessInfo, error)
+	SubscribeLogs(appKey string) (<-chan LogLine, func())
+}
+
+type processGroup struct {
+	appKey      string
+	version     string
+	installPath string
+	instances   []*processInstance
+	mu          sync.Mutex
+}
+
+type processInstance struct {
+	appKey    string
+	version   string
+	spec      ProcessSpec
+	replica   int
+	port      int
+	workDir   string
+	env       []string
+	logFile   string
+	logs      *logRegistry
+	output    *rotatingLog
+	stdout    *lineWriter
+	stderr    *lineWriter
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	startedAt time.Time
+	running   bool
+	ready     bool
+	restarts  int
+	failures  int
+	stopped   bool
+	stopCh    chan struct{}
+	done      chan struct{}
 }
 
 type processManager struct {
-	processes map[string]*ProcessInfo
+	groups map[string]*processGroup
+	logs   *logRegistry
+	hub    *logHub
+	mu     sync.Mutex
 }
 
 func NewProcessManager() ProcessManager {
 	return &processManager{
-		processes: make(map[string]*ProcessInfo),
+		groups: make(map[string]*processGroup),
+		logs:   newLogRegistry(),
+		hub:    newLogHub(),
 	}
 }
 
-func (pm *processManager) isProcessAlive(pid int) bool {
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
+func (pm *processManager) Start(appKey, version, workDir string, processes []ProcessSpec, env []string) error {
+	if err := pm.Stop(appKey); err != nil {
+		return fmt.Errorf("failed to stop existing processes: %w", err)
 	}
 
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
-}
-
-func (pm *processManager) Start(appKey, version, command, workDir string) error {
-	if existing, exists := pm.processes[appKey]; exists {
-		if pm.isProcessAlive(existing.PID) {
-			if err := pm.Stop(appKey); err != nil {
-				return fmt.Errorf("failed to stop existing process: %w", err)
+	group := &processGroup{appKey: appKey, version: version, installPath: workDir}
+	for _, spec := range processes {
+		for replica := 0; replica < max(spec.Replicas, 1); replica++ {
+			inst, err := pm.startInstance(appKey, version, workDir, spec, replica, env)
+			if err != nil {
+				group.stop()
+				return err
 			}
+			inst.markReady()
+			group.add(inst)
 		}
 	}
 
-	logFile := filepath.Join(workDir, "log.txt")
-	logF, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
+	pm.mu.Lock()
+	pm.groups[appKey] = group
+	pm.mu.Unlock()
+	return nil
+}
+
+func (pm *processManager) Upgrade(appKey, version, workDir string, processes []ProcessSpec, env []string, ready func(ProcessState) error) error {
+	pm.mu.Lock()
+	group, exists := pm.groups[appKey]
+	pm.mu.Unlock()
+
+	if !exists {
+		return pm.Start(appKey, version, workDir, processes, env)
 	}
-	defer logF.Close()
 
-	cmd := exec.Command("sh", "-c", command)
-	cmd.Dir = workDir
-	cmd.Stdout = logF
-	cmd.Stderr = logF
+	previous := group.snapshot()
+	for _, spec := range processes {
+		for replica := 0; replica < max(spec.Replicas, 1); replica++ {
+			inst, err := pm.startInstance(appKey, version, workDir, spec, replica, env)
+			if err != nil {
+				return err
+			}
+			group.add(inst)
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start command: %w", err)
+			if err := ready(inst.state()); err != nil {
+				group.remove(inst)
+				inst.stop()
+				return fmt.Errorf("%s replica %d did not become ready: %w", spec.Type, replica, err)
+			}
+			inst.markReady()
+
+			remaining := previous[:0]
+			for _, old := range previous {
+				if old.spec.Type == spec.Type && old.replica == replica {
+					group.remove(old)
+					old.stop()
+					continue
+				}
+				remaining = append(remaining, old)
+			}
+			previous = remaining
+		}
 	}
 
-	pm.processes[appKey] = &ProcessInfo{
-		PID:         cmd.Process.Pid,
-		AppKey:      appKey,
-		Version:     version,
-		InstallPath: workDir,
+	for _, old := range previous {
+		group.remove(old)
+		old.stop()
 	}
 
+	group.mu.Lock()
+	group.version = version
+	group.installPath = workDir
+	group.mu.Unlock()
 	return nil
 }
 
-func (pm *processManager) Stop(appKey string) error {
-	info, exists := pm.processes[appKey]
-	if !exists {
-		return nil
+func (pm *processManager) startInstance(appKey, version, workDir string, spec ProcessSpec, replica int, env []string) (*processInstance, error) {
+	logDir := spec.LogDir
+	if logDir == "" {
+		logDir = filepath.Join(workDir, "logs")
+	}
+
+	inst := &processInstance{
+		appKey:  appKey,
+		version: version,
+		spec:    spec,
+		replica: replica,
+		workDir: workDir,
+		env: append(env[:len(env):len(env)],
+			"ZEN_PROCESS_TYPE="+spec.Type,
+			"ZEN_REPLICA="+strconv.Itoa(replica),
+		),
+		logFile: filepath.Join(logDir, fmt.Sprintf("%s-%d.log", spec.Type, replica)),
+		logs:    pm.logs,
+		stopCh:  make(chan struct{}),
+		done:    make(chan struct{}),
 	}
 
-	process, err := os.FindProcess(info.PID)
+	if spec.Port > 0 {
+		port, err := allocatePort()
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port for %s process: %w", spec.Type, err)
+		}
+		inst.port = port
+		inst.env = append(inst.env, "PORT="+strconv.Itoa(port))
+	}
+
+	output, err := pm.logs.acquire(inst.logFile, spec.LogPolicy)
 	if err != nil {
-		delete(pm.processes, appKey)
-		return nil
+		return nil, err
+	}
+	inst.output = output
+	inst.stdout = &lineWriter{inst: inst, stream: StreamStdout, hub: pm.hub}
+	inst.stderr = &lineWriter{inst: inst, stream: StreamStderr, hub: pm.hub}
+
+	if err := inst.spawn(); err != nil {
+		pm.logs.release(output)
+		return nil, fmt.Errorf("failed to start %s process: %w", spec.Type, err)
 	}
+	go inst.supervise()
+	return inst, nil
+}
 
-	if err := process.Signal(syscall.SIGTERM); err != nil {
-		process.Kill()
+func allocatePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
 	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (pm *processManager) Stop(appKey string) error {
+	pm.mu.Lock()
+	group, exists := pm.groups[appKey]
+	delete(pm.groups, appKey)
+	pm.mu.Unlock()
 
-	delete(pm.processes, appKey)
+	if exists {
+		group.stop()
+		group.publishStopped()
+	}
 	return nil
 }
 
 func (pm *processManager) StopAll() {
-	for appKey := range pm.processes {
-		pm.Stop(appKey)
+	pm.mu.Lock()
+	groups := pm.groups
+	pm.groups = make(map[string]*processGroup)
+	pm.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			group.stop()
+			group.publishStopped()
+		}()
 	}
+	wg.Wait()
 }
 
 func (pm *processManager) IsRunning(appKey string) bool {
-	info, exists := pm.processes[appKey]
+	pm.mu.Lock()
+	group, exists := pm.groups[appKey]
+	pm.mu.Unlock()
+
 	if !exists {
 		return false
 	}
-	return pm.isProcessAlive(info.PID)
+
+	for _, inst := range group.snapshot() {
+		if inst.state().Running {
+			return true
+		}
+	}
+	return false
 }
 
 func (pm *processManager) GetProcess(appKey string) (*ProcessInfo, error) {
-	info, exists := pm.processes[appKey]
+	pm.mu.Lock()
+	group, exists := pm.groups[appKey]
+	pm.mu.Unlock()
+
 	if !exists {
 		return nil, fmt.Errorf("process not found")
 	}
+
+	group.mu.Lock()
+	info := &ProcessInfo{
+		AppKey:      group.appKey,
+		Version:     group.version,
+		InstallPath: group.installPath,
+		Processes:   make([]ProcessState, 0, len(group.instances)),
+	}
+	instances := append([]*processInstance(nil), group.instances...)
+	group.mu.Unlock()
+
+	for _, inst := range instances {
+		info.Processes = append(info.Processes, inst.state())
+	}
 	return info, nil
 }
+
+func (pm *processManager) SubscribeLogs(appKey string) (<-chan LogLine, func()) {
+	return pm.hub.Subscribe(appKey)
+}
+
+func (g *processGroup) add(inst *processInstance) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.instances = append(g.instances, inst)
+}
+
+func (g *processGroup) remove(inst *processInstance) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, candidate := range g.instances {
+		if candidate == inst {
+			g.instances = append(g.instances[:i], g.instances[i+1:]...)
+			return
+		}
+	}
+}
+
+func (g *processGroup) snapshot() []*processInstance {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]*processInstance(nil), g.instances...)
+}
+
+func (g *processGroup) publishStopped() {
+	g.mu.Lock()
+	version := g.version
+	g.mu.Unlock()
+
+	events.Publish(Event{Type: EventStopped, AppKey: g.appKey, Version: version})
+}
+
+func (g *processGroup) stop() {
+	var wg sync.WaitGroup
+	for _, inst := range g.snapshot() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inst.stop()
+		}()
+	}
+	wg.Wait()
+}
+
+func (inst *processInstance) logger() *slog.Logger {
+	return subsystemLogger("process").With("app", inst.appKey, "version", inst.version, "type", inst.spec.Type, "replica", inst.replica)
+}
+
+func (inst *processInstance) spawn() error {
+	cmd := exec.Command("sh", "-c", inst.spec.Command)
+	cmd.Dir = inst.workDir
+	cmd.Env = append(os.Environ(), inst.env...)
+	cmd.Stdout = inst.stdout
+	cmd.Stderr = inst.stderr
+	cmd.WaitDelay = stopTimeout
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	inst.cmd = cmd
+	inst.startedAt = time.Now()
+	inst.running = true
+	return nil
+}
+
+func (inst *processInstance) supervise() {
+	defer close(inst.done)
+	defer inst.logs.release(inst.output)
+
+	for {
+		inst.mu.Lock()
+		cmd := inst.cmd
+		inst.mu.Unlock()
+
+		err := cmd.Wait()
+		inst.stdout.Flush()
+		inst.stderr.Flush()
+
+		inst.mu.Lock()
+		inst.running = false
+		if inst.stopped {
+			inst.mu.Unlock()
+			return
+		}
+		if time.Since(inst.startedAt) > maxRestartDelay {
+			inst.failures = 0
+		}
+		delay := restartDelay(inst.failures)
+		inst.failures++
+		inst.mu.Unlock()
+
+		restarting := shouldRestart(inst.spec.Restart, err)
+		inst.publishCrashed(err, restarting)
+
+		if !restarting {
+			inst.logger().Info("Process exited", "error", err)
+			return
+		}
+
+		inst.logger().Warn("Process exited, restarting", "error", err, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-inst.stopCh:
+			return
+		}
+
+		inst.mu.Lock()
+		if inst.stopped {
+			inst.mu.Unlock()
+			return
+		}
+		inst.restarts++
+		processRestartsTotal.Inc(inst.appKey, inst.spec.Type)
+		err = inst.spawn()
+		inst.mu.Unlock()
+
+		if err != nil {
+			inst.logger().Error("Failed to restart process", "error", err)
+			return
+		}
+	}
+}
+
+func (inst *processInstance) publishCrashed(err error, restarting bool) {
+	data := map[string]any{
+		"process":    inst.spec.Type,
+		"replica":    inst.replica,
+		"restarting": restarting,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	events.Publish(Event{Type: EventCrashed, AppKey: inst.appKey, Version: inst.version, Data: data})
+}
+
+func (inst *processInstance) stop() {
+	inst.mu.Lock()
+	if inst.stopped {
+		inst.mu.Unlock()
+		<-inst.done
+		return
+	}
+	inst.stopped = true
+	close(inst.stopCh)
+	cmd := inst.cmd
+	running := inst.running
+	inst.mu.Unlock()
+
+	if running {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+
+	select {
+	case <-inst.done:
+	case <-time.After(stopTimeout):
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-inst.done
+	}
+}
+
+func (inst *processInstance) markReady() {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	inst.ready = true
+}
+
+func (inst *processInstance) state() ProcessState {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	return ProcessState{
+		Type:      inst.spec.Type,
+		Replica:   inst.replica,
+		Version:   inst.version,
+		PID:       inst.cmd.Process.Pid,
+		Port:      inst.port,
+		Running:   inst.running && !inst.stopped,
+		Ready:     inst.ready,
+		Restarts:  inst.restarts,
+		LogFile:   inst.logFile,
+		StartedAt: inst.startedAt,
+	}
+}
+
+func shouldRestart(policy string, err error) bool {
+	switch policy {
+	case RestartAlways:
+		return true
+	case RestartNever:
+		return false
+	default:
+		return err != nil
+	}
+}
+
+func restartDelay(failures int) time.Duration {
+	return min(time.Second<<min(failures, 5), maxRestartDelay)
+}
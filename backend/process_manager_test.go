@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestProcessManagerStartsReplicasWithOwnLogs(t *testing.T) {
+	dir := t.TempDir()
+	logDir := t.TempDir()
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	err := pm.Start("org/app", "1.0.0", dir, []ProcessSpec{
+		{Type: "web", Command: "echo web $ZEN_REPLICA; sleep 30", Replicas: 1, LogDir: logDir, LogPolicy: newLogPolicy(nil)},
+		{Type: "worker", Command: "echo worker $ZEN_REPLICA; sleep 30", Replicas: 2, LogDir: logDir, LogPolicy: newLogPolicy(nil)},
+	}, []string{"GREETING=hello"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	info, err := pm.GetProcess("org/app")
+	if err != nil {
+		t.Fatalf("Expected process info, got %v", err)
+	}
+	if info.Version != "1.0.0" || len(info.Processes) != 3 {
+		t.Fatalf("Expected 3 processes of 1.0.0, got %+v", info)
+	}
+
+	logFile := filepath.Join(logDir, "worker-1.log")
+	waitFor(t, 2*time.Second, func() bool {
+		data, _ := os.ReadFile(logFile)
+		return strings.Contains(string(data), "worker 1")
+	})
+
+	if err := pm.Stop("org/app"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pm.IsRunning("org/app") {
+		t.Error("Expected app to be stopped")
+	}
+}
+
+func TestProcessManagerRestartsFailedProcess(t *testing.T) {
+	dir := t.TempDir()
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	err := pm.Start("org/app", "1.0.0", dir, []ProcessSpec{
+		{Type: "worker", Command: "test -f started && sleep 30; touch started; exit 1", Restart: RestartOnFailure},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	waitFor(t, 3*time.Second, func() bool {
+		info, _ := pm.GetProcess("org/app")
+		return info.Processes[0].Restarts == 1 && info.Processes[0].Running
+	})
+}
+
+func TestProcessManagerHonoursNeverRestart(t *testing.T) {
+	dir := t.TempDir()
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	err := pm.Start("org/app", "1.0.0", dir, []ProcessSpec{
+		{Type: "task", Command: "exit 1", Restart: RestartNever},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	waitFor(t, 2*time.Second, func() bool {
+		return !pm.IsRunning("org/app")
+	})
+
+	info, _ := pm.GetProcess("org/app")
+	if info.Processes[0].Restarts != 0 {
+		t.Errorf("Expected no restarts, got %d", info.Processes[0].Restarts)
+	}
+}
+
+func TestProcessManagerRollingUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	specs := []ProcessSpec{{Type: "web", Command: "sleep 30", Replicas: 2, Port: 8080}}
+	if err := pm.Start("org/app", "1.0.0", dir, specs, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var observed [][]string
+	err := pm.Upgrade("org/app", "1.1.0", dir, specs, nil, func(state ProcessState) error {
+		if state.Port == 0 {
+			t.Errorf("Expected replica %d to get a port", state.Replica)
+		}
+
+		info, _ := pm.GetProcess("org/app")
+		var versions []string
+		for _, process := range info.Processes {
+			if process.Running {
+				versions = append(versions, process.Version)
+			}
+		}
+		observed = append(observed, versions)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(observed) != 2 || len(observed[0]) != 3 || len(observed[1]) != 3 {
+		t.Fatalf("Expected one extra replica during each step, got %v", observed)
+	}
+
+	info, _ := pm.GetProcess("org/app")
+	if info.Version != "1.1.0" || len(info.Processes) != 2 {
+		t.Fatalf("Expected 2 replicas of 1.1.0, got %+v", info)
+	}
+	if info.Processes[0].Port == info.Processes[1].Port {
+		t.Errorf("Expected distinct replica ports, got %d", info.Processes[0].Port)
+	}
+	for _, process := range info.Processes {
+		if process.Version != "1.1.0" || !process.Ready {
+			t.Errorf("Expected ready 1.1.0 replica, got %+v", process)
+		}
+	}
+}
+
+func TestProcessManagerUpgradeStopsAtUnreadyReplica(t *testing.T) {
+	dir := t.TempDir()
+	pm := NewProcessManager()
+	defer pm.StopAll()
+
+	specs := []ProcessSpec{{Type: "web", Command: "sleep 30", Replicas: 2}}
+	if err := pm.Start("org/app", "1.0.0", dir, specs, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err := pm.Upgrade("org/app", "1.1.0", dir, specs, nil, func(state ProcessState) error {
+		return fmt.Errorf("not listening")
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	info, _ := pm.GetProcess("org/app")
+	if len(info.Processes) != 2 {
+		t.Fatalf("Expected old replicas to keep running, got %+v", info.Processes)
+	}
+	for _, process := range info.Processes {
+		if process.Version != "1.0.0" || !process.Running {
+			t.Errorf("Expected running 1.0.0 replica, got %+v", process)
+		}
+	}
+}
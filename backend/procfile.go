@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const defaultProcessType = "web"
+
+var processTypePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func parseProcfile(data []byte) (map[string]ProcessType, error) {
+	processes := make(map[string]ProcessType)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name, command, ok := strings.Cut(text, ":")
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if !ok || !processTypePattern.MatchString(name) || command == "" {
+			return nil, fmt.Errorf("invalid Procfile entry on line %d", line)
+		}
+
+		processes[name] = ProcessType{Command: command}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return processes, nil
+}
+
+func mergeProcesses(base, overrides map[string]ProcessType) map[string]ProcessType {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]ProcessType, len(base)+len(overrides))
+	for name, process := range base {
+		merged[name] = process
+	}
+	for name, override := range overrides {
+		process := merged[name]
+		process.Command = firstNonEmpty(override.Command, process.Command)
+		process.Restart = firstNonEmpty(override.Restart, process.Restart)
+		process.Balance = firstNonEmpty(override.Balance, process.Balance)
+		process.HealthPath = firstNonEmpty(override.HealthPath, process.HealthPath)
+		if override.Replicas > 0 {
+			process.Replicas = override.Replicas
+		}
+		if override.Port > 0 {
+			process.Port = override.Port
+		}
+		merged[name] = process
+	}
+	return merged
+}
+
+func processSpecs(app App) []ProcessSpec {
+	processes := mergeProcesses(nil, app.Processes)
+	if app.Command != "" && processes[defaultProcessType].Command == "" {
+		processes = mergeProcesses(processes, map[string]ProcessType{
+			defaultProcessType: {Command: app.Command},
+		})
+	}
+
+	names := make([]string, 0, len(processes))
+	for name, process := range processes {
+		if process.Command != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	specs := make([]ProcessSpec, 0, len(names))
+	for _, name := range names {
+		process := processes[name]
+		specs = append(specs, ProcessSpec{
+			Type:       name,
+			Command:    process.Command,
+			Replicas:   max(process.Replicas, 1),
+			Restart:    firstNonEmpty(process.Restart, RestartOnFailure),
+			Port:       process.Port,
+			Balance:    firstNonEmpty(process.Balance, BalanceRoundRobin),
+			HealthPath: process.HealthPath,
+			LogDir:     appLogDir(app),
+			LogPolicy:  newLogPolicy(app.Logs),
+		})
+	}
+	return specs
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseProcfile(t *testing.T) {
+	processes, err := parseProcfile([]byte(`
+# processes for the app
+web: ./bin/server --port $PORT
+worker:   ./bin/worker
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(processes) != 2 {
+		t.Fatalf("Expected 2 processes, got %d", len(processes))
+	}
+	if processes["web"].Command != "./bin/server --port $PORT" {
+		t.Errorf("Expected web command, got '%s'", processes["web"].Command)
+	}
+	if processes["worker"].Command != "./bin/worker" {
+		t.Errorf("Expected worker command, got '%s'", processes["worker"].Command)
+	}
+}
+
+func TestParseProcfileRejectsInvalidLines(t *testing.T) {
+	for _, data := range []string{"web ./server", "web:", "web server: ./server"} {
+		if _, err := parseProcfile([]byte(data)); err == nil {
+			t.Errorf("Expected error for %q, got nil", data)
+		}
+	}
+}
+
+func TestProcessSpecs(t *testing.T) {
+	app := App{
+		Command: "./server",
+		Processes: map[string]ProcessType{
+			"worker": {Command: "./worker", Replicas: 3, Restart: RestartAlways},
+			"idle":   {Replicas: 2},
+		},
+	}
+
+	specs := processSpecs(app)
+	if len(specs) != 2 {
+		t.Fatalf("Expected 2 process specs, got %+v", specs)
+	}
+
+	if specs[0].Type != "web" || specs[0].Command != "./server" || specs[0].Replicas != 1 || specs[0].Restart != RestartOnFailure {
+		t.Errorf("Unexpected web spec %+v", specs[0])
+	}
+	if specs[1].Type != "worker" || specs[1].Replicas != 3 || specs[1].Restart != RestartAlways {
+		t.Errorf("Unexpected worker spec %+v", specs[1])
+	}
+}
+
+func TestManifestProcfileMergesWithServerConfig(t *testing.T) {
+	updater, _ := newHookTestUpdater(&mockHookRunner{})
+	fs := updater.fs.(*mockFileSystemUpdater)
+	fs.files["/opt/zen/apps/org-app-1.1.0/Procfile"] = []byte("web: ./server\nworker: ./worker\n")
+	fs.files["/opt/zen/apps/org-app-1.1.0/zen.yaml"] = []byte("processes:\n  worker:\n    replicas: 2\n")
+
+	app, err := updater.appForRelease(App{
+		Key:       "org/app",
+		Command:   "./server --verbose",
+		Processes: map[string]ProcessType{"worker": {Restart: RestartNever}},
+	}, "/opt/zen/apps/org-app-1.1.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	specs := processSpecs(app)
+	if len(specs) != 2 {
+		t.Fatalf("Expected 2 process specs, got %+v", specs)
+	}
+
+	if specs[0].Command != "./server --verbose" {
+		t.Errorf("Expected server command to override Procfile, got '%s'", specs[0].Command)
+	}
+	if specs[1].Command != "./worker" || specs[1].Replicas != 2 || specs[1].Restart != RestartNever {
+		t.Errorf("Unexpected worker spec %+v", specs[1])
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+type Release struct {
+	Version   string
+	AssetName string
+	AssetURL  string
+}
+
+type ReleaseProvider interface {
+	GetLatestRelease(app App, token string) (*Release, error)
+	DownloadRelease(app App, release *Release, token string) (io.ReadCloser, error)
+}
+
+type githubProvider struct {
+	downloader GitHubDownloader
+}
+
+func (p *githubProvider) GetLatestRelease(app App, token string) (*Release, error) {
+	release, err := p.downloader.GetLatestRelease(app.Key, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(release.Assets) == 0 {
+		return nil, fmt.Errorf("no assets found in release")
+	}
+
+	asset := release.Assets[0]
+	return &Release{
+		Version:   release.TagName,
+		AssetName: asset.Name,
+		AssetURL:  asset.BrowserDownloadURL,
+	}, nil
+}
+
+func (p *githubProvider) DownloadRelease(app App, release *Release, token string) (io.ReadCloser, error) {
+	return p.downloader.DownloadAsset(release.AssetURL, token)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPollInterval = 5 * time.Minute
+	webhookOnlySchedule = "webhook-only"
+)
+
+type pollSchedule interface {
+	Next(from time.Time) time.Time
+	DefaultJitter() time.Duration
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (s *intervalSchedule) Next(from time.Time) time.Time {
+	return from.Add(s.interval)
+}
+
+func (s *intervalSchedule) DefaultJitter() time.Duration {
+	return s.interval / 10
+}
+
+type cronSchedule struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+	anyDay   bool
+	anyWeek  bool
+}
+
+func parseSchedule(spec string) (pollSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return &intervalSchedule{interval: defaultPollInterval}, nil
+	}
+
+	if spec == webhookOnlySchedule {
+		return nil, nil
+	}
+
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d < time.Minute {
+			return nil, fmt.Errorf("poll interval %s is shorter than one minute", spec)
+		}
+		return &intervalSchedule{interval: d}, nil
+	}
+
+	return parseCron(spec)
+}
+
+func parseCron(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q, expected a duration, cron expression or %s", spec, webhookOnlySchedule)
+	}
+
+	minutes, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, err
+	}
+	hours, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, err
+	}
+	days, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, err
+	}
+	months, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, err
+	}
+	weekdays, err := parseCronField(fields[4], 0, 7)
+	if err != nil {
+		return nil, err
+	}
+	if weekdays[7] {
+		weekdays[0] = true
+	}
+
+	return &cronSchedule{
+		minutes:  minutes,
+		hours:    hours,
+		days:     days,
+		months:   months,
+		weekdays: weekdays,
+		anyDay:   fields[2] == "*",
+		anyWeek:  fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid cron step %q", part)
+			}
+			step = n
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+
+			n, err := strconv.Atoi(from)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cron value %q", part)
+			}
+			start, end = n, n
+			if hasStep {
+				end = max
+			}
+
+			if isRange {
+				n, err := strconv.Atoi(to)
+				if err != nil {
+					return nil, fmt.Errorf("invalid cron range %q", part)
+				}
+				end = n
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("cron value %q out of range %d-%d", part, min, max)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+func (s *cronSchedule) Next(from time.Time) time.Time {
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dayMatch := s.days[t.Day()]
+	weekMatch := s.weekdays[int(t.Weekday())]
+
+	switch {
+	case s.anyDay && s.anyWeek:
+		return true
+	case s.anyDay:
+		return weekMatch
+	case s.anyWeek:
+		return dayMatch
+	}
+	return dayMatch || weekMatch
+}
+
+func (s *cronSchedule) DefaultJitter() time.Duration {
+	return 0
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2025, 3, 14, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"", from.Add(5 * time.Minute)},
+		{"30m", from.Add(30 * time.Minute)},
+		{"*/15 * * * *", time.Date(2025, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2025, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2025, 3, 17, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 */2 *", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("parseSchedule(%q) returned error: %v", tt.spec, err)
+		}
+		if next := schedule.Next(from); !next.Equal(tt.expected) {
+			t.Errorf("parseSchedule(%q).Next = %s, expected %s", tt.spec, next, tt.expected)
+		}
+	}
+}
+
+func TestParseScheduleWebhookOnly(t *testing.T) {
+	schedule, err := parseSchedule("webhook-only")
+	if err != nil || schedule != nil {
+		t.Errorf("Expected nil schedule for webhook-only, got %v (%v)", schedule, err)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"10s", "* * *", "61 * * * *", "*/0 * * * *", "every day"} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("Expected error for schedule %q, got nil", spec)
+		}
+	}
+}
+
+func TestNextCheckTimeAppliesJitter(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	schedule := &intervalSchedule{interval: 10 * time.Minute}
+
+	for i := 0; i < 20; i++ {
+		next := nextCheckTime(schedule, "", now)
+		if next.Before(now.Add(10*time.Minute)) || !next.Before(now.Add(11*time.Minute)) {
+			t.Fatalf("Expected next check within default jitter, got %s", next)
+		}
+	}
+
+	if next := nextCheckTime(schedule, "0s", now); !next.Equal(now.Add(10 * time.Minute)) {
+		t.Errorf("Expected no jitter, got %s", next)
+	}
+}
@@ -1,14 +1,80 @@
 package main
 
+type GitSource struct {
+	Branch       string `json:"branch,omitempty"`
+	TagPattern   string `json:"tagPattern,omitempty"`
+	BuildCommand string `json:"buildCommand,omitempty"`
+	OutputDir    string `json:"outputDir,omitempty"`
+	BuildTimeout string `json:"buildTimeout,omitempty"`
+}
+
+type WorkflowSource struct {
+	Workflow string `json:"workflow"`
+	Branch   string `json:"branch,omitempty"`
+	Artifact string `json:"artifact"`
+}
+
+type Hooks struct {
+	PreStart  string `json:"preStart,omitempty" yaml:"preStart"`
+	PostStart string `json:"postStart,omitempty" yaml:"postStart"`
+	PreStop   string `json:"preStop,omitempty" yaml:"preStop"`
+	OnFailure string `json:"onFailure,omitempty" yaml:"onFailure"`
+	Timeout   string `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+type HealthCheck struct {
+	HTTP     string `json:"http,omitempty" yaml:"http"`
+	TCP      string `json:"tcp,omitempty" yaml:"tcp"`
+	Command  string `json:"command,omitempty" yaml:"command"`
+	Timeout  string `json:"timeout,omitempty" yaml:"timeout"`
+	Interval string `json:"interval,omitempty" yaml:"interval"`
+}
+
+type ProcessType struct {
+	Command    string `json:"command" yaml:"command"`
+	Replicas   int    `json:"replicas,omitempty" yaml:"replicas"`
+	Restart    string `json:"restart,omitempty" yaml:"restart"`
+	Port       int    `json:"port,omitempty" yaml:"port"`
+	Balance    string `json:"balance,omitempty" yaml:"balance"`
+	HealthPath string `json:"healthPath,omitempty" yaml:"healthPath"`
+}
+
+type Job struct {
+	Name     string `json:"name" yaml:"name"`
+	Schedule string `json:"schedule" yaml:"schedule"`
+	Command  string `json:"command" yaml:"command"`
+	Overlap  string `json:"overlap,omitempty" yaml:"overlap"`
+	Timeout  string `json:"timeout,omitempty" yaml:"timeout"`
+}
+
 type App struct {
-	Provider string `json:"provider"`
-	Key      string `json:"key"`
-	Command  string `json:"command"`
+	Provider      string                 `json:"provider"`
+	Key           string                 `json:"key"`
+	Command       string                 `json:"command"`
+	Credential    string                 `json:"credential,omitempty"`
+	WebhookSecret string                 `json:"webhookSecret,omitempty"`
+	Schedule      string                 `json:"schedule,omitempty"`
+	Jitter        string                 `json:"jitter,omitempty"`
+	DeployWindows []DeployWindow         `json:"deployWindows,omitempty"`
+	Timezone      string                 `json:"timezone,omitempty"`
+	Approval      string                 `json:"approval,omitempty"`
+	Env           map[string]string      `json:"env,omitempty"`
+	Hooks         *Hooks                 `json:"hooks,omitempty"`
+	Processes     map[string]ProcessType `json:"processes,omitempty"`
+	HealthCheck   *HealthCheck           `json:"healthCheck,omitempty"`
+	Jobs          []Job                  `json:"jobs,omitempty"`
+	Logs          *LogConfig             `json:"logs,omitempty"`
+	Git           *GitSource             `json:"git,omitempty"`
+	Workflow      *WorkflowSource        `json:"workflow,omitempty"`
 }
 
 type SetupData struct {
-	Username    string `json:"username"`
-	Password    string `json:"password"`
-	GithubToken string `json:"githubToken"`
-	Apps        []App  `json:"apps"`
+	Username      string         `json:"username"`
+	Password      string         `json:"password"`
+	GithubToken   string         `json:"githubToken"`
+	Apps          []App          `json:"apps"`
+	LogSinks      []LogSink      `json:"logSinks,omitempty"`
+	AlertRules    []AlertRule    `json:"alertRules,omitempty"`
+	Notifiers     []Notifier     `json:"notifiers,omitempty"`
+	EventWebhooks []EventWebhook `json:"eventWebhooks,omitempty"`
 }
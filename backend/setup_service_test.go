@@ -49,6 +49,14 @@ func (m *mockFileSystemSetup) Remove(name string) error {
 	return errors.New("not implemented")
 }
 
+func (m *mockFileSystemSetup) RemoveAll(path string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockFileSystemSetup) Rename(oldpath, newpath string) error {
+	return errors.New("not implemented")
+}
+
 func (m *mockFileSystemSetup) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return nil, errors.New("not implemented")
 }
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+
+	systemLogCapacity = 1000
+)
+
+var (
+	systemLogs     = newSystemLogBuffer(systemLogCapacity)
+	systemLogLevel = new(slog.LevelVar)
+)
+
+type SystemLogEntry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+}
+
+type SystemLogFilter struct {
+	Level     slog.Level
+	Subsystem string
+	AppKey    string
+}
+
+func (f SystemLogFilter) Matches(entry SystemLogEntry) bool {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(entry.Level)); err == nil && level < f.Level {
+		return false
+	}
+	if f.Subsystem != "" && entry.Attrs["subsystem"] != f.Subsystem {
+		return false
+	}
+	if f.AppKey != "" && entry.Attrs["app"] != f.AppKey {
+		return false
+	}
+	return true
+}
+
+type systemLogBuffer struct {
+	mu      sync.Mutex
+	entries []SystemLogEntry
+	next    int
+	full    bool
+}
+
+func newSystemLogBuffer(capacity int) *systemLogBuffer {
+	return &systemLogBuffer{entries: make([]SystemLogEntry, capacity)}
+}
+
+func (b *systemLogBuffer) Add(entry SystemLogEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+func (b *systemLogBuffer) Entries(filter SystemLogFilter, limit int) []SystemLogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ordered := b.entries[:b.next]
+	if b.full {
+		ordered = append(append([]SystemLogEntry{}, b.entries[b.next:]...), b.entries[:b.next]...)
+	}
+
+	result := []SystemLogEntry{}
+	for _, entry := range ordered {
+		if filter.Matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+type bufferedHandler struct {
+	next   slog.Handler
+	buffer *systemLogBuffer
+	attrs  map[string]any
+	prefix string
+}
+
+func newBufferedHandler(next slog.Handler, buffer *systemLogBuffer) *bufferedHandler {
+	return &bufferedHandler{next: next, buffer: buffer, attrs: map[string]any{}}
+}
+
+func (h *bufferedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *bufferedHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	for key, value := range h.attrs {
+		attrs[key] = value
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		flattenAttr(attrs, h.prefix, attr)
+		return true
+	})
+
+	entry := SystemLogEntry{
+		Time:    r.Time,
+		Level:   r.Level.String(),
+		Message: r.Message,
+	}
+	if len(attrs) > 0 {
+		entry.Attrs = attrs
+	}
+	h.buffer.Add(entry)
+	logSinks.ForwardEntry(entry)
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *bufferedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	merged := make(map[string]any, len(h.attrs)+len(attrs))
+	for key, value := range h.attrs {
+		merged[key] = value
+	}
+	for _, attr := range attrs {
+		flattenAttr(merged, h.prefix, attr)
+	}
+	return &bufferedHandler{next: h.next.WithAttrs(attrs), buffer: h.buffer, attrs: merged, prefix: h.prefix}
+}
+
+func (h *bufferedHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &bufferedHandler{next: h.next.WithGroup(name), buffer: h.buffer, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+func flattenAttr(attrs map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			flattenAttr(attrs, groupPrefix, member)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+
+	switch value.Kind() {
+	case slog.KindDuration:
+		attrs[prefix+attr.Key] = value.Duration().String()
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			attrs[prefix+attr.Key] = err.Error()
+		} else {
+			attrs[prefix+attr.Key] = value.Any()
+		}
+	default:
+		attrs[prefix+attr.Key] = value.Any()
+	}
+}
+
+func newSystemLogger(w io.Writer, format string, level slog.Leveler, buffer *systemLogBuffer) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", LogFormatText:
+		handler = slog.NewTextHandler(w, options)
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	return slog.New(newBufferedHandler(handler, buffer)), nil
+}
+
+func configureLogging(levelName, format string) error {
+	if levelName != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(levelName)); err != nil {
+			return fmt.Errorf("invalid log level %q", levelName)
+		}
+		systemLogLevel.Set(level)
+	}
+
+	logger, err := newSystemLogger(os.Stderr, format, systemLogLevel, systemLogs)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+func subsystemLogger(subsystem string) *slog.Logger {
+	return slog.Default().With("subsystem", subsystem)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func handleListSystemLogs(c *fiber.Ctx) error {
+	filter := SystemLogFilter{
+		Level:     slog.LevelDebug,
+		Subsystem: c.Query("subsystem"),
+		AppKey:    c.Query("app"),
+	}
+	if level := c.Query("level"); level != "" {
+		if err := filter.Level.UnmarshalText([]byte(level)); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid log level",
+			})
+		}
+	}
+
+	limit := min(max(c.QueryInt("limit", 200), 0), systemLogCapacity)
+	return c.JSON(systemLogs.Entries(filter, limit))
+}
+
+func handleGetLogLevel(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"level": systemLogLevel.Level().String(),
+	})
+}
+
+func handleSetLogLevel(c *fiber.Ctx) error {
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid log level",
+		})
+	}
+
+	systemLogLevel.Set(level)
+	subsystemLogger("http").Info("Log level changed", "level", level.String())
+
+	return c.JSON(fiber.Map{
+		"level": level.String(),
+	})
+}
+
+func requestLogger(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+	if err != nil {
+		if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+			c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	status := c.Response().StatusCode()
+	subsystemLogger("http").Log(c.UserContext(), requestLogLevel(status), "Request handled",
+		"method", c.Method(),
+		"path", c.Path(),
+		"status", status,
+		"latency", time.Since(start),
+		"ip", c.IP(),
+	)
+	return nil
+}
+
+func requestLogLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	}
+	return slog.LevelDebug
+}
+
+func handleListLogSinks(c *fiber.Ctx) error {
+	return c.JSON(logSinks.Status())
+}
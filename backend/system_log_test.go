@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSystemLogBufferKeepsNewestEntries(t *testing.T) {
+	buffer := newSystemLogBuffer(3)
+	for i := 0; i < 5; i++ {
+		buffer.Add(SystemLogEntry{Level: "INFO", Message: strconv.Itoa(i)})
+	}
+
+	entries := buffer.Entries(SystemLogFilter{Level: slog.LevelDebug}, 0)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	for i, expected := range []string{"2", "3", "4"} {
+		if entries[i].Message != expected {
+			t.Errorf("Expected entry %d to be '%s', got '%s'", i, expected, entries[i].Message)
+		}
+	}
+
+	entries = buffer.Entries(SystemLogFilter{Level: slog.LevelDebug}, 1)
+	if len(entries) != 1 || entries[0].Message != "4" {
+		t.Errorf("Expected only the newest entry, got %+v", entries)
+	}
+}
+
+func TestSystemLoggerRecordsStructuredEntries(t *testing.T) {
+	var out bytes.Buffer
+	buffer := newSystemLogBuffer(10)
+	level := new(slog.LevelVar)
+
+	logger, err := newSystemLogger(&out, LogFormatJSON, level, buffer)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	deploy := logger.With("subsystem", "deploy", "app", "org/app")
+	deploy.Debug("Hidden")
+	deploy.WithGroup("hook").Warn("Hook failed", "name", "pre-start", "error", errors.New("exit status 1"))
+	logger.With("subsystem", "proxy").Info("Proxying", "latency", 1500*time.Millisecond)
+
+	entries := buffer.Entries(SystemLogFilter{}, 0)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", entries)
+	}
+
+	hook := entries[0]
+	if hook.Level != "WARN" || hook.Attrs["app"] != "org/app" || hook.Attrs["hook.name"] != "pre-start" || hook.Attrs["hook.error"] != "exit status 1" {
+		t.Errorf("Unexpected entry %+v", hook)
+	}
+	if entries[1].Attrs["latency"] != "1.5s" {
+		t.Errorf("Expected duration to be formatted, got %v", entries[1].Attrs["latency"])
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(bytes.Split(out.Bytes(), []byte("\n"))[0], &record); err != nil {
+		t.Fatalf("Expected JSON output, got %q", out.String())
+	}
+	if record["subsystem"] != "deploy" || record["msg"] != "Hook failed" {
+		t.Errorf("Unexpected JSON record %v", record)
+	}
+
+	level.Set(slog.LevelDebug)
+	deploy.Debug("Visible")
+	if entries := buffer.Entries(SystemLogFilter{Level: slog.LevelDebug}, 0); len(entries) != 3 {
+		t.Errorf("Expected debug entry after lowering the level, got %d entries", len(entries))
+	}
+}
+
+func TestSystemLogFilter(t *testing.T) {
+	buffer := newSystemLogBuffer(10)
+	buffer.Add(SystemLogEntry{Level: "INFO", Message: "a", Attrs: map[string]any{"subsystem": "deploy", "app": "org/app"}})
+	buffer.Add(SystemLogEntry{Level: "ERROR", Message: "b", Attrs: map[string]any{"subsystem": "deploy", "app": "org/other"}})
+	buffer.Add(SystemLogEntry{Level: "ERROR", Message: "c", Attrs: map[string]any{"subsystem": "proxy", "app": "org/app"}})
+
+	tests := []struct {
+		filter   SystemLogFilter
+		expected string
+	}{
+		{SystemLogFilter{Level: slog.LevelError}, "bc"},
+		{SystemLogFilter{Subsystem: "deploy"}, "ab"},
+		{SystemLogFilter{AppKey: "org/app"}, "ac"},
+		{SystemLogFilter{Level: slog.LevelError, Subsystem: "deploy"}, "b"},
+	}
+
+	for _, tt := range tests {
+		var got string
+		for _, entry := range buffer.Entries(tt.filter, 0) {
+			got += entry.Message
+		}
+		if got != tt.expected {
+			t.Errorf("%+v: expected '%s', got '%s'", tt.filter, tt.expected, got)
+		}
+	}
+}
+
+func TestNewSystemLoggerRejectsUnknownFormat(t *testing.T) {
+	if _, err := newSystemLogger(&bytes.Buffer{}, "xml", slog.LevelInfo, newSystemLogBuffer(1)); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if err := configureLogging("loud", LogFormatText); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
+
+func TestRequestLogLevel(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected slog.Level
+	}{
+		{200, slog.LevelDebug},
+		{304, slog.LevelDebug},
+		{401, slog.LevelWarn},
+		{404, slog.LevelWarn},
+		{502, slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		if got := requestLogLevel(tt.status); got != tt.expected {
+			t.Errorf("requestLogLevel(%d) = %s, expected %s", tt.status, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	usageSampleInterval = 30 * time.Second
+	usageRetention      = 7 * 24 * time.Hour
+	usageDayFormat      = "2006-01-02"
+)
+
+type ResourceSample struct {
+	Time             time.Time `json:"time"`
+	Type             string    `json:"type"`
+	Replica          int       `json:"replica"`
+	Version          string    `json:"version"`
+	Processes        int       `json:"processes"`
+	CPUPercent       float64   `json:"cpuPercent"`
+	CPUSeconds       float64   `json:"cpuSeconds"`
+	RSSBytes         int64     `json:"rssBytes"`
+	Threads          int       `json:"threads"`
+	OpenFiles        int       `json:"openFiles"`
+	ReadBytes        int64     `json:"readBytes"`
+	WriteBytes       int64     `json:"writeBytes"`
+	ReadBytesPerSec  float64   `json:"readBytesPerSec"`
+	WriteBytesPerSec float64   `json:"writeBytesPerSec"`
+}
+
+type AppUsage struct {
+	AppKey     string    `json:"appKey"`
+	Time       time.Time `json:"time"`
+	Processes  int       `json:"processes"`
+	CPUPercent float64   `json:"cpuPercent"`
+	RSSBytes   int64     `json:"rssBytes"`
+	Threads    int       `json:"threads"`
+	OpenFiles  int       `json:"openFiles"`
+}
+
+type usageCounters struct {
+	pid        int
+	time       time.Time
+	cpuSeconds float64
+	readBytes  int64
+	writeBytes int64
+}
+
+type usageStore struct {
+	dir      string
+	procRoot string
+	mu       sync.Mutex
+	previous map[string]usageCounters
+	latest   map[string][]ResourceSample
+}
+
+func newUsageStore(dir string) *usageStore {
+	return &usageStore{
+		dir:      dir,
+		procRoot: procRoot,
+		previous: make(map[string]usageCounters),
+		latest:   make(map[string][]ResourceSample),
+	}
+}
+
+func (s *usageStore) appDir(appKey string) string {
+	return filepath.Join(s.dir, toSlug(appKey))
+}
+
+func (s *usageStore) sample(appKey string, info *ProcessInfo, groups map[int]processStats, now time.Time) []ResourceSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var samples []ResourceSample
+	seen := make(map[string]bool)
+	for _, state := range info.Processes {
+		if !state.Running {
+			continue
+		}
+
+		stats, ok := groups[state.PID]
+		if !ok {
+			continue
+		}
+
+		sample := ResourceSample{
+			Time:       now,
+			Type:       state.Type,
+			Replica:    state.Replica,
+			Version:    state.Version,
+			Processes:  stats.Processes,
+			CPUSeconds: stats.CPUSeconds,
+			RSSBytes:   stats.RSSBytes,
+			Threads:    stats.Threads,
+			OpenFiles:  stats.OpenFDs,
+			ReadBytes:  stats.ReadBytes,
+			WriteBytes: stats.WriteBytes,
+		}
+
+		key := appKey + "\xff" + state.Type + "\xff" + strconv.Itoa(state.Replica)
+		seen[key] = true
+		if prev, ok := s.previous[key]; ok && prev.pid == state.PID {
+			if elapsed := now.Sub(prev.time).Seconds(); elapsed > 0 {
+				sample.CPUPercent = max(stats.CPUSeconds-prev.cpuSeconds, 0) / elapsed * 100
+				sample.ReadBytesPerSec = float64(max(stats.ReadBytes-prev.readBytes, 0)) / elapsed
+				sample.WriteBytesPerSec = float64(max(stats.WriteBytes-prev.writeBytes, 0)) / elapsed
+			}
+		}
+		s.previous[key] = usageCounters{
+			pid:        state.PID,
+			time:       now,
+			cpuSeconds: stats.CPUSeconds,
+			readBytes:  stats.ReadBytes,
+			writeBytes: stats.WriteBytes,
+		}
+
+		samples = append(samples, sample)
+	}
+
+	for key := range s.previous {
+		if strings.HasPrefix(key, appKey+"\xff") && !seen[key] {
+			delete(s.previous, key)
+		}
+	}
+	s.latest[appKey] = samples
+	return samples
+}
+
+func (s *usageStore) Append(appKey string, samples []ResourceSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	dir := s.appDir(appKey)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, samples[0].Time.UTC().Format(usageDayFormat)+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, sample := range samples {
+		if err := encoder.Encode(sample); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (s *usageStore) Query(appKey string, since, until time.Time) ([]ResourceSample, error) {
+	entries, err := os.ReadDir(s.appDir(appKey))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ResourceSample{}, nil
+		}
+		return nil, err
+	}
+
+	samples := []ResourceSample{}
+	for _, entry := range entries {
+		day, err := time.Parse(usageDayFormat, strings.TrimSuffix(entry.Name(), ".jsonl"))
+		if err != nil || day.Add(24*time.Hour).Before(since) || day.After(until) {
+			continue
+		}
+
+		daySamples, err := readUsageFile(filepath.Join(s.appDir(appKey), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range daySamples {
+			if !sample.Time.Before(since) && !sample.Time.After(until) {
+				samples = append(samples, sample)
+			}
+		}
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+	return samples, nil
+}
+
+func readUsageFile(path string) ([]ResourceSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []ResourceSample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sample ResourceSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+func (s *usageStore) Prune(now time.Time) {
+	apps, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+
+	cutoff := now.Add(-usageRetention).UTC().Format(usageDayFormat)
+	for _, app := range apps {
+		dir := filepath.Join(s.dir, app.Name())
+		days, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, day := range days {
+			if strings.TrimSuffix(day.Name(), ".jsonl") < cutoff {
+				if err := os.Remove(filepath.Join(dir, day.Name())); err != nil {
+					subsystemLogger("usage").Error("Failed to remove old usage file", "path", filepath.Join(dir, day.Name()), "error", err)
+				}
+			}
+		}
+	}
+}
+
+func (s *usageStore) retain(configured map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for appKey := range s.latest {
+		if !configured[appKey] {
+			delete(s.latest, appKey)
+		}
+	}
+}
+
+func (s *usageStore) Latest() []AppUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := []AppUsage{}
+	for appKey, samples := range s.latest {
+		if len(samples) == 0 {
+			continue
+		}
+
+		total := AppUsage{AppKey: appKey, Time: samples[0].Time}
+		for _, sample := range samples {
+			total.Processes += sample.Processes
+			total.CPUPercent += sample.CPUPercent
+			total.RSSBytes += sample.RSSBytes
+			total.Threads += sample.Threads
+			total.OpenFiles += sample.OpenFiles
+		}
+		usage = append(usage, total)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].CPUPercent != usage[j].CPUPercent {
+			return usage[i].CPUPercent > usage[j].CPUPercent
+		}
+		return usage[i].RSSBytes > usage[j].RSSBytes
+	})
+	return usage
+}
+
+func downsampleUsage(samples []ResourceSample, step time.Duration) []ResourceSample {
+	if step <= 0 {
+		return samples
+	}
+
+	type bucketKey struct {
+		start   time.Time
+		process string
+	}
+	buckets := make(map[bucketKey][]ResourceSample)
+	var order []bucketKey
+	for _, sample := range samples {
+		key := bucketKey{start: sample.Time.Truncate(step), process: sample.Type + "." + strconv.Itoa(sample.Replica)}
+		if _, ok := buckets[key]; !ok {
+			order = append(order, key)
+		}
+		buckets[key] = append(buckets[key], sample)
+	}
+
+	result := make([]ResourceSample, 0, len(order))
+	for _, key := range order {
+		group := buckets[key]
+		merged := group[len(group)-1]
+		merged.Time = key.start
+
+		var cpu, readRate, writeRate float64
+		for _, sample := range group {
+			cpu += sample.CPUPercent
+			readRate += sample.ReadBytesPerSec
+			writeRate += sample.WriteBytesPerSec
+			merged.RSSBytes = max(merged.RSSBytes, sample.RSSBytes)
+			merged.Threads = max(merged.Threads, sample.Threads)
+			merged.OpenFiles = max(merged.OpenFiles, sample.OpenFiles)
+		}
+		n := float64(len(group))
+		merged.CPUPercent = cpu / n
+		merged.ReadBytesPerSec = readRate / n
+		merged.WriteBytesPerSec = writeRate / n
+		result = append(result, merged)
+	}
+	return result
+}
+
+func (au *AppUpdater) sampleUsage(now time.Time) {
+	setupData, err := au.loadSetupData()
+	if err != nil {
+		subsystemLogger("usage").Error("Failed to load setup data", "error", err)
+		return
+	}
+
+	configured := make(map[string]bool)
+	infos := make([]*ProcessInfo, 0, len(setupData.Apps))
+	for _, app := range setupData.Apps {
+		configured[app.Key] = true
+
+		info, err := au.ProcessManager.GetProcess(app.Key)
+		if err != nil {
+			info = &ProcessInfo{AppKey: app.Key}
+		}
+		infos = append(infos, info)
+	}
+
+	groups, err := processGroupStats(au.usage.procRoot, runningPIDs(infos...))
+	if err != nil {
+		subsystemLogger("usage").Error("Failed to read process stats", "error", err)
+		return
+	}
+
+	for i, app := range setupData.Apps {
+		samples := au.usage.sample(app.Key, infos[i], groups, now)
+		if err := au.usage.Append(app.Key, samples); err != nil {
+			subsystemLogger("usage").Error("Failed to store usage samples", "app", app.Key, "error", err)
+		}
+	}
+	au.usage.retain(configured)
+}
+
+func (au *AppUpdater) collectUsage() {
+	ticker := time.NewTicker(usageSampleInterval)
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+	au.usage.Prune(lastPrune)
+	for now := range ticker.C {
+		au.sampleUsage(now)
+		if now.Sub(lastPrune) >= time.Hour {
+			au.usage.Prune(now)
+			lastPrune = now
+		}
+	}
+}
+
+func (au *AppUpdater) Usage(appKey string, since, until time.Time, step time.Duration) ([]ResourceSample, error) {
+	samples, err := au.usage.Query(appKey, since, until)
+	if err != nil {
+		return nil, err
+	}
+	return downsampleUsage(samples, step), nil
+}
+
+func (au *AppUpdater) UsageSummary() []AppUsage {
+	return au.usage.Latest()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFakeProc(t *testing.T, root string, pid, pgrp int, ticks, threads, rssPages int, readBytes, writeBytes int64) {
+	t.Helper()
+
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	os.MkdirAll(filepath.Join(dir, "fd"), 0755)
+	stat := fmt.Sprintf("%d (app) S 1 %d %d 0 -1 0 0 0 0 0 %d 0 0 0 20 0 %d 0 100 1000 %d 0", pid, pgrp, pgrp, ticks, threads, rssPages)
+	os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644)
+	io := fmt.Sprintf("rchar: 1\nwchar: 2\nread_bytes: %d\nwrite_bytes: %d\n", readBytes, writeBytes)
+	os.WriteFile(filepath.Join(dir, "io"), []byte(io), 0644)
+	os.WriteFile(filepath.Join(dir, "fd", "0"), nil, 0644)
+}
+
+func TestUsageStoreSamplesRates(t *testing.T) {
+	root := t.TempDir()
+	store := newUsageStore(t.TempDir())
+	store.procRoot = root
+
+	info := &ProcessInfo{AppKey: "org/app", Processes: []ProcessState{
+		{Type: "web", Replica: 0, Version: "1.0.0", PID: 100, Running: true},
+		{Type: "worker", Replica: 0, Version: "1.0.0", PID: 200, Running: false},
+	}}
+	start := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	groups := func() map[int]processStats {
+		groups, err := processGroupStats(root, runningPIDs(info))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return groups
+	}
+
+	writeFakeProc(t, root, 100, 100, 100, 4, 10, 0, 0)
+	writeFakeProc(t, root, 101, 100, 50, 2, 5, 1000, 0)
+	samples := store.sample("org/app", info, groups(), start)
+	if len(samples) != 1 {
+		t.Fatalf("Expected 1 sample for running process, got %d", len(samples))
+	}
+	first := samples[0]
+	if first.Processes != 2 || first.Threads != 6 || first.CPUSeconds != 1.5 || first.OpenFiles != 2 || first.CPUPercent != 0 {
+		t.Errorf("Unexpected first sample %+v", first)
+	}
+	if first.RSSBytes != 15*int64(os.Getpagesize()) || first.ReadBytes != 1000 {
+		t.Errorf("Unexpected memory or I/O in %+v", first)
+	}
+
+	writeFakeProc(t, root, 100, 100, 400, 4, 10, 0, 3000)
+	writeFakeProc(t, root, 101, 100, 50, 2, 5, 1000, 0)
+	second := store.sample("org/app", info, groups(), start.Add(10*time.Second))[0]
+	if second.CPUPercent != 30 || second.WriteBytesPerSec != 300 || second.ReadBytesPerSec != 0 {
+		t.Errorf("Expected rates from previous sample, got %+v", second)
+	}
+
+	info.Processes[0].PID = 300
+	writeFakeProc(t, root, 300, 300, 900, 1, 1, 0, 0)
+	restarted := store.sample("org/app", info, groups(), start.Add(20*time.Second))[0]
+	if restarted.CPUPercent != 0 {
+		t.Errorf("Expected rate to reset after restart, got %v", restarted.CPUPercent)
+	}
+}
+
+func TestUsageStorePersistsAndQueries(t *testing.T) {
+	dir := t.TempDir()
+	store := newUsageStore(dir)
+
+	day1 := time.Date(2024, 6, 1, 23, 59, 30, 0, time.UTC)
+	day2 := day1.Add(time.Minute)
+	old := day1.Add(-10 * 24 * time.Hour)
+
+	for _, ts := range []time.Time{old, day1, day2} {
+		if err := store.Append("org/app", []ResourceSample{{Time: ts, Type: "web", RSSBytes: 1}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	samples, err := store.Query("org/app", day1.Add(-time.Hour), day2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(samples) != 2 || !samples[0].Time.Equal(day1) || !samples[1].Time.Equal(day2) {
+		t.Errorf("Expected samples across both days, got %+v", samples)
+	}
+
+	store.Prune(day2)
+	if _, err := os.Stat(filepath.Join(dir, "org-app", old.Format(usageDayFormat)+".jsonl")); !os.IsNotExist(err) {
+		t.Error("Expected old usage file to be pruned")
+	}
+	if samples, _ := store.Query("org/app", old, day2); len(samples) != 2 {
+		t.Errorf("Expected recent samples to remain, got %d", len(samples))
+	}
+
+	if samples, err := store.Query("org/missing", old, day2); err != nil || len(samples) != 0 {
+		t.Errorf("Expected empty result for unknown app, got %v %v", samples, err)
+	}
+}
+
+func TestDownsampleUsage(t *testing.T) {
+	base := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	samples := []ResourceSample{
+		{Time: base, Type: "web", CPUPercent: 10, RSSBytes: 100},
+		{Time: base.Add(30 * time.Second), Type: "web", CPUPercent: 30, RSSBytes: 300},
+		{Time: base.Add(30 * time.Second), Type: "worker", CPUPercent: 5, RSSBytes: 50},
+		{Time: base.Add(time.Minute), Type: "web", CPUPercent: 50, RSSBytes: 200},
+	}
+
+	result := downsampleUsage(samples, time.Minute)
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 buckets, got %+v", result)
+	}
+	if result[0].Type != "web" || result[0].CPUPercent != 20 || result[0].RSSBytes != 300 || !result[0].Time.Equal(base) {
+		t.Errorf("Unexpected first bucket %+v", result[0])
+	}
+	if result[1].Type != "worker" || result[2].CPUPercent != 50 {
+		t.Errorf("Unexpected buckets %+v", result)
+	}
+}
+
+func TestUsageStoreLatestSortsByCPU(t *testing.T) {
+	store := newUsageStore(t.TempDir())
+	now := time.Now()
+	store.latest["org/quiet"] = []ResourceSample{{Time: now, CPUPercent: 1, RSSBytes: 900}}
+	store.latest["org/busy"] = []ResourceSample{{Time: now, CPUPercent: 40, Processes: 1}, {Time: now, CPUPercent: 35, Processes: 2}}
+	store.latest["org/removed"] = []ResourceSample{{Time: now, CPUPercent: 99}}
+
+	store.retain(map[string]bool{"org/quiet": true, "org/busy": true})
+	usage := store.Latest()
+	if len(usage) != 2 || usage[0].AppKey != "org/busy" || usage[0].CPUPercent != 75 || usage[0].Processes != 3 {
+		t.Errorf("Unexpected summary %+v", usage)
+	}
+}
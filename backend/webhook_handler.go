@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var webhookService *WebhookService
+
+func handleGitHubWebhook(c *fiber.Ctx) error {
+	triggered, err := webhookService.HandleGitHub(
+		c.Get("X-GitHub-Delivery"),
+		c.Get("X-GitHub-Event"),
+		c.Get("X-Hub-Signature-256"),
+		c.Body(),
+	)
+
+	switch {
+	case errors.Is(err, ErrWebhookDuplicate):
+		return c.JSON(fiber.Map{
+			"duplicate": true,
+		})
+	case errors.Is(err, ErrWebhookInvalidSignature):
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Invalid signature",
+		})
+	case errors.Is(err, ErrWebhookAppNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"error": "No app configured for repository",
+		})
+	case errors.Is(err, ErrWebhookInvalidPayload):
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid webhook payload",
+		})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to process webhook",
+		})
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"triggered": triggered,
+	})
+}
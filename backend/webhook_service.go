@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrWebhookAppNotFound      = errors.New("no app configured for repository")
+	ErrWebhookInvalidSignature = errors.New("invalid webhook signature")
+	ErrWebhookDuplicate        = errors.New("duplicate delivery")
+	ErrWebhookInvalidPayload   = errors.New("invalid webhook payload")
+)
+
+type UpdateTrigger interface {
+	TriggerUpdate(appKey string)
+}
+
+type WebhookService struct {
+	fs            FileSystemOps
+	trigger       UpdateTrigger
+	setupFilePath string
+	deliveries    *deliveryCache
+}
+
+func NewWebhookService(fs FileSystemOps, trigger UpdateTrigger, setupFilePath string) *WebhookService {
+	return &WebhookService{
+		fs:            fs,
+		trigger:       trigger,
+		setupFilePath: setupFilePath,
+		deliveries:    newDeliveryCache(1000),
+	}
+}
+
+type githubWebhookPayload struct {
+	Action     string `json:"action"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+func (s *WebhookService) HandleGitHub(deliveryID, event, signature string, body []byte) ([]string, error) {
+	var payload githubWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrWebhookInvalidPayload
+	}
+
+	setupData, err := s.loadSetupData()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []App
+	for _, app := range setupData.Apps {
+		if app.Provider == "github" && app.WebhookSecret != "" && strings.EqualFold(app.Key, payload.Repository.FullName) {
+			candidates = append(candidates, app)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrWebhookAppNotFound
+	}
+
+	var verified []App
+	for _, app := range candidates {
+		if verifyGitHubSignature(app.WebhookSecret, signature, body) {
+			verified = append(verified, app)
+		}
+	}
+
+	if len(verified) == 0 {
+		subsystemLogger("webhook").Warn("Rejected delivery with invalid signature", "delivery", deliveryID, "repo", payload.Repository.FullName)
+		return nil, ErrWebhookInvalidSignature
+	}
+
+	if !s.deliveries.add(deliveryID) {
+		subsystemLogger("webhook").Info("Ignoring duplicate delivery", "delivery", deliveryID)
+		return nil, ErrWebhookDuplicate
+	}
+
+	subsystemLogger("webhook").Info("Received delivery", "delivery", deliveryID, "event", event, "action", payload.Action, "repo", payload.Repository.FullName)
+
+	if event != "release" || (payload.Action != "published" && payload.Action != "released") {
+		return []string{}, nil
+	}
+
+	triggered := make([]string, 0, len(verified))
+	for _, app := range verified {
+		s.trigger.TriggerUpdate(app.Key)
+		triggered = append(triggered, app.Key)
+	}
+
+	return triggered, nil
+}
+
+func (s *WebhookService) loadSetupData() (*SetupData, error) {
+	data, err := s.fs.ReadFile(s.setupFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var setupData SetupData
+	if err := json.Unmarshal(data, &setupData); err != nil {
+		return nil, err
+	}
+
+	return &setupData, nil
+}
+
+func verifyGitHubSignature(secret, signature string, body []byte) bool {
+	expected, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+
+	decoded, err := hex.DecodeString(expected)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), decoded)
+}
+
+type deliveryCache struct {
+	mu    sync.Mutex
+	limit int
+	seen  map[string]struct{}
+	order []string
+}
+
+func newDeliveryCache(limit int) *deliveryCache {
+	return &deliveryCache{
+		limit: limit,
+		seen:  make(map[string]struct{}),
+	}
+}
+
+func (c *deliveryCache) add(id string) bool {
+	if id == "" {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.seen[id]; ok {
+		return false
+	}
+
+	c.seen[id] = struct{}{}
+	c.order = append(c.order, id)
+	if len(c.order) > c.limit {
+		delete(c.seen, c.order[0])
+		c.order = c.order[1:]
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type mockUpdateTrigger struct {
+	triggered []string
+}
+
+func (m *mockUpdateTrigger) TriggerUpdate(appKey string) {
+	m.triggered = append(m.triggered, appKey)
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestWebhookService(trigger UpdateTrigger) *WebhookService {
+	fs := newMockFileSystem()
+	data, _ := json.Marshal(SetupData{
+		Apps: []App{
+			{Provider: "github", Key: "org/app", WebhookSecret: "s3cret"},
+			{Provider: "github", Key: "org/other", WebhookSecret: "other"},
+		},
+	})
+	fs.files["/test/setup.json"] = data
+	return NewWebhookService(fs, trigger, "/test/setup.json")
+}
+
+func TestWebhookTriggersUpdateForVerifiedRelease(t *testing.T) {
+	trigger := &mockUpdateTrigger{}
+	service := newTestWebhookService(trigger)
+
+	body := []byte(`{"action":"published","repository":{"full_name":"Org/App"}}`)
+	triggered, err := service.HandleGitHub("delivery-1", "release", signWebhook("s3cret", body), body)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(triggered) != 1 || len(trigger.triggered) != 1 || trigger.triggered[0] != "org/app" {
+		t.Errorf("Expected org/app to be triggered, got %v", trigger.triggered)
+	}
+}
+
+func TestWebhookRejectsInvalidSignature(t *testing.T) {
+	trigger := &mockUpdateTrigger{}
+	service := newTestWebhookService(trigger)
+
+	body := []byte(`{"action":"published","repository":{"full_name":"org/app"}}`)
+	_, err := service.HandleGitHub("delivery-1", "release", signWebhook("other", body), body)
+	if !errors.Is(err, ErrWebhookInvalidSignature) {
+		t.Errorf("Expected invalid signature error, got %v", err)
+	}
+
+	if len(trigger.triggered) != 0 {
+		t.Errorf("Expected no updates triggered, got %v", trigger.triggered)
+	}
+}
+
+func TestWebhookDeduplicatesDeliveries(t *testing.T) {
+	trigger := &mockUpdateTrigger{}
+	service := newTestWebhookService(trigger)
+
+	body := []byte(`{"action":"published","repository":{"full_name":"org/app"}}`)
+	signature := signWebhook("s3cret", body)
+
+	service.HandleGitHub("delivery-1", "release", signature, body)
+	_, err := service.HandleGitHub("delivery-1", "release", signature, body)
+	if !errors.Is(err, ErrWebhookDuplicate) {
+		t.Errorf("Expected duplicate delivery error, got %v", err)
+	}
+
+	if len(trigger.triggered) != 1 {
+		t.Errorf("Expected a single triggered update, got %v", trigger.triggered)
+	}
+}
+
+func TestWebhookIgnoresNonReleaseEvents(t *testing.T) {
+	trigger := &mockUpdateTrigger{}
+	service := newTestWebhookService(trigger)
+
+	body := []byte(`{"action":"created","repository":{"full_name":"org/app"}}`)
+	triggered, err := service.HandleGitHub("delivery-2", "push", signWebhook("s3cret", body), body)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(triggered) != 0 || len(trigger.triggered) != 0 {
+		t.Errorf("Expected no updates triggered, got %v", trigger.triggered)
+	}
+}
+
+func TestDeliveryCacheEvictsOldest(t *testing.T) {
+	cache := newDeliveryCache(2)
+	cache.add("a")
+	cache.add("b")
+	cache.add("c")
+
+	if !cache.add("a") {
+		t.Error("Expected evicted delivery to be accepted again")
+	}
+	if cache.add("c") {
+		t.Error("Expected recent delivery to be rejected")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type workflowRun struct {
+	ID         int64  `json:"id"`
+	HeadSHA    string `json:"head_sha"`
+	HeadBranch string `json:"head_branch"`
+}
+
+type workflowArtifact struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	ArchiveDownloadURL string `json:"archive_download_url"`
+	Expired            bool   `json:"expired"`
+}
+
+type workflowProvider struct {
+	client     HTTPClient
+	apiBaseURL string
+}
+
+func (p *workflowProvider) GetLatestRelease(app App, token string) (*Release, error) {
+	source := app.Workflow
+	if source == nil || source.Workflow == "" || source.Artifact == "" {
+		return nil, fmt.Errorf("workflow and artifact must be configured for %s", app.Key)
+	}
+
+	query := url.Values{}
+	query.Set("status", "success")
+	query.Set("per_page", "10")
+	if source.Branch != "" {
+		query.Set("branch", source.Branch)
+	}
+
+	var runs struct {
+		WorkflowRuns []workflowRun `json:"workflow_runs"`
+	}
+	runsURL := fmt.Sprintf("%s/repos/%s/actions/workflows/%s/runs?%s",
+		p.apiBaseURL, app.Key, url.PathEscape(source.Workflow), query.Encode())
+	if err := p.getJSON(runsURL, token, &runs); err != nil {
+		return nil, fmt.Errorf("failed to list workflow runs: %w", err)
+	}
+
+	for _, run := range runs.WorkflowRuns {
+		var artifacts struct {
+			Artifacts []workflowArtifact `json:"artifacts"`
+		}
+		artifactsURL := fmt.Sprintf("%s/repos/%s/actions/runs/%d/artifacts?name=%s",
+			p.apiBaseURL, app.Key, run.ID, url.QueryEscape(source.Artifact))
+		if err := p.getJSON(artifactsURL, token, &artifacts); err != nil {
+			return nil, fmt.Errorf("failed to list artifacts for run %d: %w", run.ID, err)
+		}
+
+		for _, artifact := range artifacts.Artifacts {
+			if artifact.Name != source.Artifact || artifact.Expired {
+				continue
+			}
+
+			sha := run.HeadSHA
+			if len(sha) > 7 {
+				sha = sha[:7]
+			}
+
+			return &Release{
+				Version:   fmt.Sprintf("run%d-%s", run.ID, sha),
+				AssetName: artifact.Name + ".zip",
+				AssetURL:  artifact.ArchiveDownloadURL,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no successful run of %s with artifact %s found", source.Workflow, source.Artifact)
+}
+
+func (p *workflowProvider) DownloadRelease(app App, release *Release, token string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", release.AssetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("artifact download failed with status %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+func (p *workflowProvider) getJSON(url, token string, v any) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWorkflowProviderFindsLatestArtifact(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/app/actions/workflows/build.yml/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("branch") != "main" || r.URL.Query().Get("status") != "success" {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"workflow_runs": []workflowRun{
+				{ID: 42, HeadSHA: "abcdef1234567890"},
+				{ID: 41, HeadSHA: "1234567890abcdef"},
+			},
+		})
+	})
+	mux.HandleFunc("/repos/org/app/actions/runs/42/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"artifacts": []workflowArtifact{
+				{ID: 7, Name: "dist", Expired: true, ArchiveDownloadURL: server.URL + "/expired"},
+			},
+		})
+	})
+	mux.HandleFunc("/repos/org/app/actions/runs/41/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"artifacts": []workflowArtifact{
+				{ID: 6, Name: "dist", ArchiveDownloadURL: server.URL + "/artifacts/6/zip"},
+			},
+		})
+	})
+	mux.HandleFunc("/artifacts/6/zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("zip-bytes"))
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := &workflowProvider{client: server.Client(), apiBaseURL: server.URL}
+	app := App{
+		Provider: "github-actions",
+		Key:      "org/app",
+		Workflow: &WorkflowSource{Workflow: "build.yml", Branch: "main", Artifact: "dist"},
+	}
+
+	release, err := provider.GetLatestRelease(app, "token")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if release.Version != "run41-1234567" {
+		t.Errorf("Expected version 'run41-1234567', got '%s'", release.Version)
+	}
+
+	if release.AssetName != "dist.zip" {
+		t.Errorf("Expected asset 'dist.zip', got '%s'", release.AssetName)
+	}
+
+	body, err := provider.DownloadRelease(app, release, "token")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer body.Close()
+
+	data, _ := io.ReadAll(body)
+	if string(data) != "zip-bytes" {
+		t.Errorf("Expected 'zip-bytes', got '%s'", data)
+	}
+}
+
+func TestWorkflowProviderRequiresConfig(t *testing.T) {
+	provider := &workflowProvider{client: &mockHTTPClient{}, apiBaseURL: githubAPIBaseURL}
+
+	if _, err := provider.GetLatestRelease(App{Provider: "github-actions", Key: "org/app"}, "token"); err == nil {
+		t.Error("Expected error for missing workflow config, got nil")
+	}
+}
@@ -1,6 +1,7 @@
 import { Container, Title, Button, Paper } from '@mantine/core'
 import { useAuth } from '../../auth-context'
 import { useNavigate } from 'react-router-dom'
+import { PendingReleases } from './pending-releases'
 
 export default function Dashboard() {
   const { logout } = useAuth()
@@ -15,7 +16,8 @@ export default function Dashboard() {
     <Container size="lg" my={40}>
       <Paper withBorder shadow="md" p={30} radius="md">
         <Title mb="xl">Dashboard</Title>
-        <Button onClick={handleLogout}>Logout</Button>
+        <PendingReleases />
+        <Button mt="xl" onClick={handleLogout}>Logout</Button>
       </Paper>
     </Container>
   )
@@ -0,0 +1,101 @@
+import { Button, Group, Paper, Stack, Text, Title, Badge } from "@mantine/core";
+import { useCallback, useEffect, useState } from "react";
+
+interface PendingRelease {
+  appKey: string;
+  version: string;
+  reason: string;
+  detectedAt: string;
+  applyAfter?: string;
+}
+
+const toSlug = (key: string) =>
+  key
+    .toLowerCase()
+    .replace(/\//g, "-")
+    .replace(/[^a-z0-9-]+/g, "-")
+    .replace(/^-+|-+$/g, "");
+
+export function PendingReleases() {
+  const [releases, setReleases] = useState<PendingRelease[]>([]);
+  const [error, setError] = useState<string | null>(null);
+
+  const load = useCallback(async () => {
+    const response = await fetch("/api/apps/pending", {
+      credentials: "include",
+    });
+    if (!response.ok) {
+      setError("Failed to load pending releases");
+      return;
+    }
+    setError(null);
+    setReleases(await response.json());
+  }, []);
+
+  useEffect(() => {
+    load();
+  }, [load]);
+
+  const decide = async (
+    release: PendingRelease,
+    decision: "approve" | "reject"
+  ) => {
+    const response = await fetch(
+      `/api/apps/${toSlug(release.appKey)}/releases/${encodeURIComponent(
+        release.version
+      )}/${decision}`,
+      { method: "POST", credentials: "include" }
+    );
+    if (!response.ok) {
+      const data = await response.json();
+      setError(data.error || `Failed to ${decision} release`);
+    }
+    await load();
+  };
+
+  return (
+    <Stack>
+      <Title order={3}>Pending releases</Title>
+      {error && <Text c="red">{error}</Text>}
+      {releases.length === 0 && (
+        <Text c="dimmed">No releases are waiting to be deployed</Text>
+      )}
+      {releases.map((release) => (
+        <Paper key={release.appKey} p="md" withBorder>
+          <Group justify="space-between">
+            <Stack gap={4}>
+              <Text fw={500}>
+                {release.appKey} {release.version}
+              </Text>
+              <Group gap="xs">
+                <Badge variant="light">{release.reason}</Badge>
+                <Text size="sm" c="dimmed">
+                  detected {new Date(release.detectedAt).toLocaleString()}
+                </Text>
+                {release.applyAfter && (
+                  <Text size="sm" c="dimmed">
+                    applies {new Date(release.applyAfter).toLocaleString()}
+                  </Text>
+                )}
+              </Group>
+            </Stack>
+            {release.reason === "awaiting-approval" && (
+              <Group>
+                <Button onClick={() => decide(release, "approve")}>
+                  Approve
+                </Button>
+                <Button
+                  variant="subtle"
+                  color="red"
+                  onClick={() => decide(release, "reject")}
+                >
+                  Reject
+                </Button>
+              </Group>
+            )}
+          </Group>
+        </Paper>
+      ))}
+    </Stack>
+  );
+}
@@ -29,8 +29,10 @@ export const AppsStep = forwardRef<AppsStepRef, AppsStepProps>(
       },
       validate: {
         apps: {
-          key: (value) => {
+          key: (value, values, path) => {
             if (!value) return "Key is required";
+            const index = Number(path.split(".")[1]);
+            if (values.apps[index]?.provider === "local") return null;
             if (!value.includes("/")) return "Key must be in user/repo format";
             return null;
           },
@@ -75,13 +77,19 @@ export const AppsStep = forwardRef<AppsStepRef, AppsStepProps>(
               <Stack>
                 <Select
                   label="Provider"
-                  data={[{ value: "github", label: "GitHub" }]}
+                  data={[
+                    { value: "github", label: "GitHub" },
+                    { value: "oci", label: "OCI Registry" },
+                    { value: "local", label: "Local Inbox" },
+                    { value: "git", label: "Git Repository" },
+                    { value: "github-actions", label: "GitHub Actions Artifact" },
+                  ]}
                   {...form.getInputProps(`apps.${index}.provider`)}
                 />
                 <TextInput
                   label="Key"
                   placeholder="user/repo"
-                  description="Repository in user/repo format"
+                  description="Repository in user/repo format, or registry/repository[:tag] for OCI"
                   {...form.getInputProps(`apps.${index}.key`)}
                 />
                 <Textarea